@@ -0,0 +1,98 @@
+package myreplication
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	_AUTH_NATIVE_PASSWORD       = "mysql_native_password"
+	_AUTH_CACHING_SHA2_PASSWORD = "caching_sha2_password"
+	_AUTH_SHA256_PASSWORD       = "sha256_password"
+
+	_AUTH_MORE_DATA      = 0x01
+	_AUTH_SWITCH_REQUEST = 0xFE
+
+	_CACHING_SHA2_REQUEST_PUBLIC_KEY = 0x02
+	_CACHING_SHA2_FAST_AUTH_SUCCESS  = 0x03
+	_CACHING_SHA2_PERFORM_FULL_AUTH  = 0x04
+
+	_SHA256_REQUEST_PUBLIC_KEY = 0x01
+)
+
+type (
+	authenticator struct {
+		plugin   string
+		scramble []byte
+		password string
+		secure   bool
+
+		waitPublicKey bool
+	}
+)
+
+// initialResponse is the auth data sent in the handshake response or after an AuthSwitchRequest
+func (a *authenticator) initialResponse() ([]byte, error) {
+	switch a.plugin {
+	case _AUTH_NATIVE_PASSWORD:
+		return encryptedPasswd(a.password, a.scramble), nil
+	case _AUTH_CACHING_SHA2_PASSWORD:
+		return scrambleSHA256Password(a.password, a.scramble), nil
+	case _AUTH_SHA256_PASSWORD:
+		if len(a.password) == 0 {
+			return []byte{0}, nil
+		}
+		if a.secure {
+			return append([]byte(a.password), 0), nil
+		}
+		a.waitPublicKey = true
+		return []byte{_SHA256_REQUEST_PUBLIC_KEY}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported auth plugin %s", a.plugin)
+}
+
+// moreData answers an AuthMoreData packet, nil means nothing has to be sent
+func (a *authenticator) moreData(data []byte) ([]byte, error) {
+	if a.waitPublicKey {
+		a.waitPublicKey = false
+		return encryptPasswordRSA(a.password, a.scramble, data)
+	}
+
+	if a.plugin != _AUTH_CACHING_SHA2_PASSWORD || len(data) == 0 {
+		return nil, errors.New("unexpected auth more data packet")
+	}
+
+	switch data[0] {
+	case _CACHING_SHA2_FAST_AUTH_SUCCESS:
+		return nil, nil
+	case _CACHING_SHA2_PERFORM_FULL_AUTH:
+		if a.secure {
+			return append([]byte(a.password), 0), nil
+		}
+		a.waitPublicKey = true
+		return []byte{_CACHING_SHA2_REQUEST_PUBLIC_KEY}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected caching_sha2_password state %d", data[0])
+}
+
+// switchPlugin handles http://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::AuthSwitchRequest
+func (a *authenticator) switchPlugin(pack *pack) ([]byte, error) {
+	pack.ReadByte()
+	name, err := pack.readNilString()
+	if err != nil {
+		return nil, err
+	}
+
+	scramble := pack.Bytes()
+	if len(scramble) > 0 && scramble[len(scramble)-1] == 0 {
+		scramble = scramble[:len(scramble)-1]
+	}
+
+	a.plugin = string(name)
+	a.scramble = append([]byte{}, scramble...)
+	a.waitPublicKey = false
+
+	return a.initialResponse()
+}
@@ -0,0 +1,266 @@
+package myreplication
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+var (
+	errStandInAccessDenied = errors.New("Access denied")
+)
+
+func checkCachingSHA2Scramble(password string, scramble, authData []byte) error {
+	stg1 := sha256.Sum256([]byte(password))
+	stored := sha256.Sum256(stg1[:])
+
+	crypt := sha256.New()
+	crypt.Write(stored[:])
+	crypt.Write(scramble)
+	stg3 := crypt.Sum(nil)
+
+	if len(authData) != len(stg3) {
+		return errStandInAccessDenied
+	}
+
+	candidate := make([]byte, len(stg3))
+	for i := range stg3 {
+		candidate[i] = authData[i] ^ stg3[i]
+	}
+
+	if sha256.Sum256(candidate) != stored {
+		return errStandInAccessDenied
+	}
+
+	return nil
+}
+
+func checkNativeScramble(password string, scramble, authData []byte) error {
+	if !bytes.Equal(encryptedPasswd(password, scramble), authData) {
+		return errStandInAccessDenied
+	}
+	return nil
+}
+
+func rsaFullAuth(t *testing.T, session *standInSession, key *rsa.PrivateKey, password string) error {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal("Marshal public key fail", err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	if err = session.write(append([]byte{_AUTH_MORE_DATA}, publicKey...)...); err != nil {
+		return err
+	}
+
+	encrypted, err := session.read()
+	if err != nil {
+		return err
+	}
+
+	plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, key, encrypted, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := range plain {
+		plain[i] ^= standInScramble[i%len(standInScramble)]
+	}
+
+	if string(plain) != password+"\x00" {
+		return errStandInAccessDenied
+	}
+
+	return nil
+}
+
+func TestAuthPlugins(t *testing.T) {
+	certificate, _ := newSelfSignedCertificate(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Generate rsa key fail", err)
+	}
+
+	switchScramble := []byte("abcdefghij0123456789")
+
+	type authTestCase struct {
+		name          string
+		pluginName    string
+		tlsMode       TLSMode
+		password      string
+		authenticate  func(session *standInSession, authData []byte) error
+		expectedError bool
+	}
+
+	testCases := []*authTestCase{
+		&authTestCase{
+			name:       "native",
+			pluginName: _AUTH_NATIVE_PASSWORD,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				return checkNativeScramble("secret", standInScramble, authData)
+			},
+		},
+		&authTestCase{
+			name:       "native wrong password",
+			pluginName: _AUTH_NATIVE_PASSWORD,
+			password:   "wrong",
+			authenticate: func(session *standInSession, authData []byte) error {
+				return checkNativeScramble("secret", standInScramble, authData)
+			},
+			expectedError: true,
+		},
+		&authTestCase{
+			name:       "caching_sha2 fast auth",
+			pluginName: _AUTH_CACHING_SHA2_PASSWORD,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				if err := checkCachingSHA2Scramble("secret", standInScramble, authData); err != nil {
+					return err
+				}
+				return session.write(_AUTH_MORE_DATA, _CACHING_SHA2_FAST_AUTH_SUCCESS)
+			},
+		},
+		&authTestCase{
+			name:       "auth switch to caching_sha2",
+			pluginName: _AUTH_NATIVE_PASSWORD,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				switchRequest := []byte{_AUTH_SWITCH_REQUEST}
+				switchRequest = append(switchRequest, []byte(_AUTH_CACHING_SHA2_PASSWORD)...)
+				switchRequest = append(switchRequest, 0)
+				switchRequest = append(switchRequest, switchScramble...)
+				switchRequest = append(switchRequest, 0)
+				if err := session.write(switchRequest...); err != nil {
+					return err
+				}
+
+				authData, err := session.read()
+				if err != nil {
+					return err
+				}
+
+				if err = checkCachingSHA2Scramble("secret", switchScramble, authData); err != nil {
+					return err
+				}
+				return session.write(_AUTH_MORE_DATA, _CACHING_SHA2_FAST_AUTH_SUCCESS)
+			},
+		},
+		&authTestCase{
+			name:       "caching_sha2 full auth with rsa",
+			pluginName: _AUTH_CACHING_SHA2_PASSWORD,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				if err := session.write(_AUTH_MORE_DATA, _CACHING_SHA2_PERFORM_FULL_AUTH); err != nil {
+					return err
+				}
+
+				request, err := session.read()
+				if err != nil {
+					return err
+				}
+
+				if !bytes.Equal(request, []byte{_CACHING_SHA2_REQUEST_PUBLIC_KEY}) {
+					return errors.New("public key is not requested")
+				}
+
+				return rsaFullAuth(t, session, key, "secret")
+			},
+		},
+		&authTestCase{
+			name:       "caching_sha2 full auth over tls",
+			pluginName: _AUTH_CACHING_SHA2_PASSWORD,
+			tlsMode:    TLS_MODE_REQUIRED,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				if err := session.write(_AUTH_MORE_DATA, _CACHING_SHA2_PERFORM_FULL_AUTH); err != nil {
+					return err
+				}
+
+				password, err := session.read()
+				if err != nil {
+					return err
+				}
+
+				if !session.secure || string(password) != "secret\x00" {
+					return errStandInAccessDenied
+				}
+
+				return nil
+			},
+		},
+		&authTestCase{
+			name:       "sha256_password with rsa",
+			pluginName: _AUTH_SHA256_PASSWORD,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				if !bytes.Equal(authData, []byte{_SHA256_REQUEST_PUBLIC_KEY}) {
+					return errors.New("public key is not requested")
+				}
+
+				return rsaFullAuth(t, session, key, "secret")
+			},
+		},
+		&authTestCase{
+			name:       "sha256_password over tls",
+			pluginName: _AUTH_SHA256_PASSWORD,
+			tlsMode:    TLS_MODE_REQUIRED,
+			password:   "secret",
+			authenticate: func(session *standInSession, authData []byte) error {
+				if !session.secure || string(authData) != "secret\x00" {
+					return errStandInAccessDenied
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		server := newStandInServer(t, certificate, testCase.tlsMode != TLS_MODE_DISABLED)
+		server.pluginName = testCase.pluginName
+		server.authenticate = testCase.authenticate
+		server.start()
+
+		connection := NewConnection()
+		connection.SetTLS(testCase.tlsMode, nil)
+		err := connection.ConnectAndAuth("127.0.0.1", server.port(), "repl", testCase.password)
+
+		if testCase.expectedError && err == nil {
+			t.Fatal("Expected auth error at test", testCase.name)
+		}
+
+		if !testCase.expectedError && err != nil {
+			t.Fatal("Auth fail at test", testCase.name, err)
+		}
+
+		if err == nil {
+			connection.Close()
+		}
+		server.close()
+	}
+}
+
+func TestScrambleSHA256Password(t *testing.T) {
+	scramble := []byte("123456789abcdefghijk")
+
+	result := scrambleSHA256Password("secret", scramble)
+
+	if err := checkCachingSHA2Scramble("secret", scramble, result); err != nil {
+		t.Fatal("Incorrect caching_sha2_password scramble", result)
+	}
+
+	if result := scrambleSHA256Password("", scramble); len(result) != 0 {
+		t.Fatal(
+			"Incorrect empty password scramble",
+			"expected", []byte{},
+			"got", result,
+		)
+	}
+}
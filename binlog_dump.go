@@ -21,3 +21,23 @@ func (bd *binlogDump) writeServer(position uint32, fileName string, serverId uin
 
 	return pack
 }
+
+func (bd *binlogDump) writeServerGTID(gtidSet *GTIDSet, serverId uint32) *pack {
+	//command
+	pack := newPack()
+
+	pack.WriteByte(byte(_COM_BINLOG_DUMP_GTID))
+	//flags
+	pack.writeUInt16(uint16(_BINLOG_THROUGH_GTID))
+	//server id
+	pack.writeUInt32(serverId)
+	//empty filename and position, server finds them by the gtid set
+	pack.writeUInt32(0)
+	pack.writeUInt64(4)
+	//gtid set
+	data := gtidSet.encode()
+	pack.writeUInt32(uint32(len(data)))
+	pack.Write(data)
+
+	return pack
+}
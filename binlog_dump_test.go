@@ -89,3 +89,36 @@ func TestStartBinLog(t *testing.T) {
 		)
 	}
 }
+
+func TestStartBinLogGTID(t *testing.T) {
+	rs := binlogDump{}
+
+	gtidSet, _ := ParseGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5")
+
+	pack := rs.writeServerGTID(gtidSet, uint32(5))
+
+	result := pack.packBytes()
+
+	expected := []byte{
+		0x47, 0x00, 0x00, 0x00,
+		_COM_BINLOG_DUMP_GTID,
+		//flags
+		0x04, 0x00,
+		//server id
+		0x05, 0x00, 0x00, 0x00,
+		//file name length and position
+		0x00, 0x00, 0x00, 0x00,
+		0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		//gtid set length
+		0x30, 0x00, 0x00, 0x00,
+	}
+	expected = append(expected, gtidSet.encode()...)
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatal(
+			"Incorrect binlog dump gtid packet",
+			"expected", expected,
+			"got", result,
+		)
+	}
+}
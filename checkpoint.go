@@ -0,0 +1,178 @@
+package myreplication
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type (
+	// Checkpoint is the replication progress, GTIDSet is nil unless the
+	// stream is resumed by gtid
+	Checkpoint struct {
+		Position Position
+		GTIDSet  *GTIDSet
+	}
+
+	// CheckpointStore keeps the last committed checkpoint, Load returns nil
+	// when nothing is saved yet
+	CheckpointStore interface {
+		Load() (*Checkpoint, error)
+		Save(checkpoint *Checkpoint) error
+	}
+
+	// FileCheckpointStore saves the checkpoint to a file, a crash during Save
+	// leaves the previous checkpoint in place
+	FileCheckpointStore struct {
+		path string
+	}
+
+	MemoryCheckpointStore struct {
+		lock       sync.Mutex
+		checkpoint *Checkpoint
+	}
+
+	fileCheckpoint struct {
+		File     string `json:"file"`
+		Position uint32 `json:"position"`
+		GTIDSet  string `json:"gtid_set,omitempty"`
+	}
+)
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{
+		path: path,
+	}
+}
+
+func (f *FileCheckpointStore) Load() (*Checkpoint, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	saved := &fileCheckpoint{}
+	if err = json.Unmarshal(data, saved); err != nil {
+		return nil, err
+	}
+
+	checkpoint := &Checkpoint{
+		Position: Position{saved.File, saved.Position},
+	}
+	if saved.GTIDSet != "" {
+		if checkpoint.GTIDSet, err = ParseGTIDSet(saved.GTIDSet); err != nil {
+			return nil, err
+		}
+	}
+
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file in the same directory,
+// syncs it and renames it over the previous one
+func (f *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	saved := &fileCheckpoint{
+		File:     checkpoint.Position.File,
+		Position: checkpoint.Position.Pos,
+	}
+	if checkpoint.GTIDSet != nil {
+		saved.GTIDSet = checkpoint.GTIDSet.String()
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if err = writeAndSync(tmp, data); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), f.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	//the rename itself is durable only after the directory is synced
+	return syncDir(dir)
+}
+
+func writeAndSync(file *os.File, data []byte) error {
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{}
+}
+
+func (m *MemoryCheckpointStore) Load() (*Checkpoint, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.checkpoint.clone(), nil
+}
+
+func (m *MemoryCheckpointStore) Save(checkpoint *Checkpoint) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.checkpoint = checkpoint.clone()
+	return nil
+}
+
+func (c *Checkpoint) clone() *Checkpoint {
+	if c == nil {
+		return nil
+	}
+
+	clone := &Checkpoint{
+		Position: c.Position,
+	}
+	if c.GTIDSet != nil {
+		clone.GTIDSet = c.GTIDSet.Clone()
+	}
+	return clone
+}
+
+func (c *Checkpoint) equal(other *Checkpoint) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+
+	if c.GTIDSet == nil || other.GTIDSet == nil {
+		return c.Position == other.Position && c.GTIDSet == other.GTIDSet
+	}
+
+	return c.Position == other.Position && c.GTIDSet.Equal(other.GTIDSet)
+}
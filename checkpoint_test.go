@@ -0,0 +1,111 @@
+package myreplication
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "checkpoint")
+	if err != nil {
+		t.Fatal("Temp dir fail", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileCheckpointStore(filepath.Join(dir, "replication.checkpoint"))
+
+	checkpoint, err := store.Load()
+	if err != nil || checkpoint != nil {
+		t.Fatal("Incorrect missing checkpoint", "expected", nil, "got", checkpoint, err)
+	}
+
+	gtidSet, _ := ParseGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5")
+
+	testCases := []*Checkpoint{
+		&Checkpoint{Position{"mysql-bin.000003", 120}, nil},
+		&Checkpoint{Position{"mysql-bin.000004", 4}, gtidSet},
+	}
+
+	for i, testCase := range testCases {
+		if err := store.Save(testCase); err != nil {
+			t.Fatal("Save fail at test", i, err)
+		}
+
+		checkpoint, err := store.Load()
+		if err != nil {
+			t.Fatal("Load fail at test", i, err)
+		}
+
+		if !checkpoint.equal(testCase) {
+			t.Fatal(
+				"Incorrect checkpoint at test", i,
+				"expected", testCase.Position, testCase.GTIDSet,
+				"got", checkpoint.Position, checkpoint.GTIDSet,
+			)
+		}
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatal("Incorrect files count", "expected", 1, "got", len(files))
+	}
+}
+
+func TestStreamerCheckpoint(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	master.appendQuery("BEGIN")
+	master.appendIntVar(1)
+	firstCommit := master.appendXid(10)
+	master.appendQuery("BEGIN")
+	master.appendIntVar(2)
+	master.appendXid(11)
+	master.appendQuery("CREATE TABLE t (id int)")
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+	cfg.ServerId = 2
+
+	store := NewMemoryCheckpointStore()
+	store.Save(&Checkpoint{Position: Position{master.fileName, firstCommit}})
+
+	streamer := NewStreamer(cfg, master.fileName, 4)
+	defer streamer.Close()
+	if err := streamer.SetCheckpointStore(store); err != nil {
+		t.Fatal("Set checkpoint store fail", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	//the checkpoint moves only at the end of a transaction
+	expectedPositions := []uint32{firstCommit, firstCommit, master.nextPosition()}
+
+	for i, expectedPosition := range expectedPositions {
+		if _, err := streamer.GetEvent(ctx); err != nil {
+			t.Fatal("Get event fail at", i, err)
+		}
+
+		if err := streamer.Commit(); err != nil {
+			t.Fatal("Commit fail at", i, err)
+		}
+
+		checkpoint, _ := store.Load()
+		if checkpoint.Position != (Position{master.fileName, expectedPosition}) {
+			t.Fatal(
+				"Incorrect checkpoint at", i,
+				"expected", expectedPosition,
+				"got", checkpoint.Position,
+			)
+		}
+	}
+
+	if dump := <-master.dumps; dump.position != firstCommit {
+		t.Fatal("Incorrect dump position", "expected", firstCommit, "got", dump.position)
+	}
+}
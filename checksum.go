@@ -0,0 +1,150 @@
+package myreplication
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+)
+
+const (
+	_BINLOG_CHECKSUM_ALG_OFF   = 0x00
+	_BINLOG_CHECKSUM_ALG_CRC32 = 0x01
+	_BINLOG_CHECKSUM_ALG_UNDEF = 0xff
+
+	_BINLOG_CHECKSUM_LENGTH     = 4
+	_BINLOG_CHECKSUM_ALG_LENGTH = 1
+)
+
+var (
+	// servers before 5.6.1 do not write the checksum algorithm into FORMAT_DESCRIPTION_EVENT
+	checksumVersion = []int{5, 6, 1}
+)
+
+type (
+	// ChecksumMismatchError is returned for an event whose CRC32 does not match its data
+	ChecksumMismatchError struct {
+		FileName  string
+		Position  uint32
+		EventType byte
+		Expected  uint32
+		Actual    uint32
+	}
+)
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf(
+		"binlog checksum mismatch for event 0x%02x at %s:%d, expected %08x, got %08x",
+		e.EventType, e.FileName, e.Position, e.Expected, e.Actual,
+	)
+}
+
+// SetChecksumVerification turns off the CRC32 check of the events, the
+// checksum is still removed from the event data
+func (ev *EventLog) SetChecksumVerification(verify bool) {
+	ev.skipChecksum = !verify
+}
+
+// checksum removes the checksum from the event of the pack and verifies it.
+// The algorithm comes from FORMAT_DESCRIPTION_EVENT, before it the checksum
+// negotiated by ChecksumCompatibility is expected.
+func (ev *EventLog) checksum(pack *pack) error {
+	//ok byte and event header
+	if len(pack.buff) < 20 {
+		return nil
+	}
+	data := pack.buff[1:]
+
+	alg := ev.checksumAlg
+	length := 0
+
+	switch {
+	case data[4] == _FORMAT_DESCRIPTION_EVENT:
+		alg = formatDescriptionChecksumAlg(data)
+		ev.checksumAlg = alg
+		if alg != _BINLOG_CHECKSUM_ALG_UNDEF {
+			//the algorithm and the checksum value are written even when it is off
+			length = _BINLOG_CHECKSUM_ALG_LENGTH + _BINLOG_CHECKSUM_LENGTH
+		}
+	case alg == _BINLOG_CHECKSUM_ALG_UNDEF && ev.additionalLength > 0:
+		alg = _BINLOG_CHECKSUM_ALG_CRC32
+		length = _BINLOG_CHECKSUM_LENGTH
+	case alg == _BINLOG_CHECKSUM_ALG_CRC32:
+		length = _BINLOG_CHECKSUM_LENGTH
+	}
+
+	if length == 0 || len(data) < 19+length {
+		return nil
+	}
+
+	if alg == _BINLOG_CHECKSUM_ALG_CRC32 && !ev.skipChecksum {
+		var expected uint32
+		readUint32(data[len(data)-_BINLOG_CHECKSUM_LENGTH:], &expected)
+
+		if actual := crc32.ChecksumIEEE(data[:len(data)-_BINLOG_CHECKSUM_LENGTH]); actual != expected {
+			var size, nextPosition uint32
+			readUint32(data[9:13], &size)
+			readUint32(data[13:17], &nextPosition)
+
+			//artificial events like the ROTATE_EVENT and FORMAT_DESCRIPTION_EVENT
+			//at the start of the dump have no log position
+			position := ev.lastRotatePosition
+			if nextPosition >= size {
+				position = nextPosition - size
+			}
+
+			return &ChecksumMismatchError{
+				FileName:  string(ev.lastRotateFileName),
+				Position:  position,
+				EventType: data[4],
+				Expected:  expected,
+				Actual:    actual,
+			}
+		}
+	}
+
+	pack.buff = pack.buff[:len(pack.buff)-length]
+	pack.Truncate(pack.Len() - length)
+	return nil
+}
+
+// formatDescriptionChecksumAlg reads the algorithm byte before the checksum
+// value at the end of the event, undefined for old servers
+func formatDescriptionChecksumAlg(data []byte) byte {
+	//header, binlog version, server version
+	if len(data) < 19+2+50+_BINLOG_CHECKSUM_ALG_LENGTH+_BINLOG_CHECKSUM_LENGTH {
+		return _BINLOG_CHECKSUM_ALG_UNDEF
+	}
+
+	if !versionAtLeast(string(data[21:71]), checksumVersion) {
+		return _BINLOG_CHECKSUM_ALG_UNDEF
+	}
+
+	return data[len(data)-_BINLOG_CHECKSUM_LENGTH-_BINLOG_CHECKSUM_ALG_LENGTH]
+}
+
+// versionAtLeast compares the leading numbers of a server version like 5.6.10-log
+func versionAtLeast(version string, minimum []int) bool {
+	end := strings.IndexFunc(version, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	})
+	if end >= 0 {
+		version = version[:end]
+	}
+
+	parts := strings.Split(version, ".")
+	for i, number := range minimum {
+		if i >= len(parts) {
+			return false
+		}
+
+		part, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return false
+		}
+		if part != number {
+			return part > number
+		}
+	}
+	return true
+}
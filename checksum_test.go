@@ -0,0 +1,134 @@
+package myreplication
+
+import (
+	"hash/crc32"
+	"testing"
+)
+
+func standInChecksum(event []byte) []byte {
+	checksum := make([]byte, 4)
+	writeUInt32(checksum, crc32.ChecksumIEEE(event))
+	return append(event, checksum...)
+}
+
+func TestEventChecksum(t *testing.T) {
+	formatDescription := standInFormatDescriptionBody()
+	formatDescription[len(formatDescription)-5] = _BINLOG_CHECKSUM_ALG_CRC32
+
+	//event size and positions include the checksum
+	intVar := standInEvent(_INTVAR_EVENT, 200, append(standInIntVarBody(1), 0, 0, 0, 0))
+	intVar = standInChecksum(intVar[:len(intVar)-4])
+
+	corrupted := standInEvent(_INTVAR_EVENT, 300, append(standInIntVarBody(2), 0, 0, 0, 0))
+	corrupted = standInChecksum(corrupted[:len(corrupted)-4])
+	corrupted[20]++
+
+	rotate := standInEvent(_ROTATE_EVENT, 0, append(standInRotateBody("mysql-bin.000002", 4), 0, 0, 0, 0))
+	rotate = standInChecksum(rotate[:len(rotate)-4])
+
+	fde := standInEvent(_FORMAT_DESCRIPTION_EVENT, 0, formatDescription)
+	fde = standInChecksum(fde[:len(fde)-4])
+
+	type checksumTestCase struct {
+		verify   bool
+		expected []interface{}
+	}
+
+	testCases := []*checksumTestCase{
+		&checksumTestCase{true, []interface{}{uint64(1), &ChecksumMismatchError{"mysql-bin.000002", 300 - uint32(len(corrupted)), _INTVAR_EVENT, 0, 0}}},
+		&checksumTestCase{false, []interface{}{uint64(1), uint64(3)}},
+	}
+
+	for i, testCase := range testCases {
+		connection, server := newPipeConnection()
+		el := newEventLog(connection, 4)
+		el.SetChecksumVerification(testCase.verify)
+
+		go writeStandInPackets(server, rotate, fde, intVar, corrupted)
+
+		for j, expected := range testCase.expected {
+			event, err := el.GetEvent()
+
+			switch expected := expected.(type) {
+			case uint64:
+				intVarEvent, ok := event.(*IntVarEvent)
+				if err != nil || !ok || intVarEvent.GetValue() != expected {
+					t.Fatal(
+						"Incorrect event at test", i, j,
+						"expected", expected,
+						"got", event, err,
+					)
+				}
+			case *ChecksumMismatchError:
+				mismatch, ok := err.(*ChecksumMismatchError)
+				if !ok || mismatch.FileName != expected.FileName || mismatch.Position != expected.Position ||
+					mismatch.EventType != expected.EventType || mismatch.Expected == mismatch.Actual {
+					t.Fatal(
+						"Incorrect checksum error at test", i, j,
+						"expected", expected,
+						"got", err,
+					)
+				}
+			}
+		}
+
+		server.Close()
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	type versionTestCase struct {
+		version  string
+		expected bool
+	}
+
+	testCases := []*versionTestCase{
+		&versionTestCase{"5.6.1", true},
+		&versionTestCase{"5.6.10-log", true},
+		&versionTestCase{"5.5.40-0ubuntu0.14.04.1", false},
+		&versionTestCase{"8.0.21\x00\x00\x00", true},
+		&versionTestCase{"10.1.0-MariaDB", true},
+		&versionTestCase{"5.6", false},
+		&versionTestCase{"", false},
+	}
+
+	for i, testCase := range testCases {
+		if versionAtLeast(testCase.version, checksumVersion) != testCase.expected {
+			t.Fatal(
+				"Incorrect version compare at test", i,
+				"expected", testCase.expected,
+				"got", !testCase.expected,
+			)
+		}
+	}
+}
+
+func TestArtificialEventChecksum(t *testing.T) {
+	formatDescription := standInFormatDescriptionBody()
+	formatDescription[len(formatDescription)-5] = _BINLOG_CHECKSUM_ALG_CRC32
+
+	rotate := standInEvent(_ROTATE_EVENT, 0, append(standInRotateBody("mysql-bin.000002", 120), 0, 0, 0, 0))
+	rotate = standInChecksum(rotate[:len(rotate)-4])
+
+	//the FORMAT_DESCRIPTION_EVENT sent at the start of the dump has no log position
+	fde := standInEvent(_FORMAT_DESCRIPTION_EVENT, 0, formatDescription)
+	fde = standInChecksum(fde[:len(fde)-4])
+	fde[19]++
+
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 4)
+
+	go writeStandInPackets(server, rotate, fde)
+
+	_, err := el.GetEvent()
+	mismatch, ok := err.(*ChecksumMismatchError)
+	if !ok || mismatch.FileName != "mysql-bin.000002" || mismatch.Position != 120 ||
+		mismatch.EventType != _FORMAT_DESCRIPTION_EVENT {
+		t.Fatal(
+			"Incorrect checksum error",
+			"expected", &ChecksumMismatchError{"mysql-bin.000002", 120, _FORMAT_DESCRIPTION_EVENT, 0, 0},
+			"got", err,
+		)
+	}
+}
@@ -0,0 +1,381 @@
+package myreplication
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Config holds everything needed to connect to a master, it can be built
+	// from a go-sql-driver style DSN with ParseDSN
+	Config struct {
+		User   string
+		Passwd string
+		Net    string
+		Addr   string
+		DBName string
+
+		ServerId        uint32
+		Charset         string
+		Timeout         time.Duration
+		ReadTimeout     time.Duration
+		WriteTimeout    time.Duration
+		HeartbeatPeriod time.Duration
+		// the binlog stream fails with HEARTBEAT_LOST_ERR when nothing arrives
+		// during this many heartbeat periods, 0 disables the watchdog
+		HeartbeatMisses int
+		// the binlog dump acts as a semi-sync replica, see EventLog.Ack
+		SemiSync bool
+		// location of the decoded DATE, DATETIME and TIMESTAMP values, nil
+		// is time.Local
+		Loc *time.Location
+
+		TLSMode   TLSMode
+		TLSConfig *tls.Config
+
+		// unknown DSN parameters are kept here, they are not sent to the server
+		Params map[string]string
+
+		tlsName string
+	}
+)
+
+var (
+	tlsConfigLock     sync.RWMutex
+	tlsConfigRegistry = map[string]*tls.Config{}
+
+	// default collation id for the charset sent in the handshake
+	charsetCollations = map[string]byte{
+		"big5":    1,
+		"latin1":  8,
+		"latin2":  9,
+		"ascii":   11,
+		"ujis":    12,
+		"sjis":    13,
+		"euckr":   19,
+		"gb2312":  24,
+		"gbk":     28,
+		"utf8":    33,
+		"ucs2":    35,
+		"utf8mb4": 45,
+		"cp1251":  51,
+		"utf16":   54,
+		"utf32":   60,
+		"binary":  63,
+	}
+)
+
+func NewConfig() *Config {
+	return &Config{
+		Net:    "tcp",
+		Addr:   "127.0.0.1:3306",
+		DBName: _DEFAULT_DB,
+	}
+}
+
+// RegisterTLSConfig makes a custom tls config usable from a DSN with tls=<name>
+func RegisterTLSConfig(name string, config *tls.Config) error {
+	switch strings.ToLower(name) {
+	case "true", "false", "skip-verify", "preferred", "disabled", "required", "verify-ca", "verify-identity":
+		return fmt.Errorf("tls config name %s is reserved", name)
+	}
+
+	tlsConfigLock.Lock()
+	tlsConfigRegistry[name] = config
+	tlsConfigLock.Unlock()
+	return nil
+}
+
+func DeregisterTLSConfig(name string) {
+	tlsConfigLock.Lock()
+	delete(tlsConfigRegistry, name)
+	tlsConfigLock.Unlock()
+}
+
+func getTLSConfig(name string) (*tls.Config, bool) {
+	tlsConfigLock.RLock()
+	config, ok := tlsConfigRegistry[name]
+	tlsConfigLock.RUnlock()
+	return config, ok
+}
+
+// ParseDSN parses [user[:password]@][net[(addr)]]/dbname[?param1=value1&paramN=valueN]
+func ParseDSN(dsn string) (*Config, error) {
+	cfg := NewConfig()
+
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return nil, errors.New("invalid DSN: missing the slash separating the database name")
+	}
+
+	if at := strings.LastIndex(dsn[:slash], "@"); at >= 0 {
+		userInfo := dsn[:at]
+		if colon := strings.Index(userInfo, ":"); colon >= 0 {
+			cfg.User = userInfo[:colon]
+			cfg.Passwd = userInfo[colon+1:]
+		} else {
+			cfg.User = userInfo
+		}
+		dsn = dsn[at+1:]
+		slash -= at + 1
+	}
+
+	if err := cfg.parseAddress(dsn[:slash]); err != nil {
+		return nil, err
+	}
+
+	dbName := dsn[slash+1:]
+	if question := strings.Index(dbName, "?"); question >= 0 {
+		if err := cfg.parseParams(dbName[question+1:]); err != nil {
+			return nil, err
+		}
+		dbName = dbName[:question]
+	}
+
+	if dbName != "" {
+		cfg.DBName = dbName
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) parseAddress(address string) error {
+	if address == "" {
+		return nil
+	}
+
+	open := strings.Index(address, "(")
+	if open < 0 {
+		cfg.Net = address
+		if cfg.Net == "unix" {
+			cfg.Addr = "/tmp/mysql.sock"
+		}
+		return nil
+	}
+
+	if !strings.HasSuffix(address, ")") {
+		return errors.New("invalid DSN: network address not terminated (missing closing brace)")
+	}
+
+	cfg.Net = address[:open]
+	cfg.Addr = address[open+1 : len(address)-1]
+
+	switch cfg.Net {
+	case "tcp":
+		if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+			cfg.Addr = net.JoinHostPort(cfg.Addr, "3306")
+		}
+	case "unix":
+	default:
+		return fmt.Errorf("invalid DSN: unsupported network %s", cfg.Net)
+	}
+
+	return nil
+}
+
+func (cfg *Config) parseParams(params string) (err error) {
+	for _, param := range strings.Split(params, "&") {
+		if param == "" {
+			continue
+		}
+
+		var key, value string
+		if equal := strings.Index(param, "="); equal >= 0 {
+			key, value = param[:equal], param[equal+1:]
+		} else {
+			key = param
+		}
+
+		if value, err = url.QueryUnescape(value); err != nil {
+			return
+		}
+
+		switch key {
+		case "timeout":
+			cfg.Timeout, err = time.ParseDuration(value)
+		case "readTimeout":
+			cfg.ReadTimeout, err = time.ParseDuration(value)
+		case "writeTimeout":
+			cfg.WriteTimeout, err = time.ParseDuration(value)
+		case "heartbeatPeriod":
+			cfg.HeartbeatPeriod, err = time.ParseDuration(value)
+		case "heartbeatMisses":
+			var misses uint64
+			misses, err = strconv.ParseUint(value, 10, 16)
+			cfg.HeartbeatMisses = int(misses)
+		case "semiSync":
+			cfg.SemiSync, err = strconv.ParseBool(value)
+		case "serverId":
+			var id uint64
+			id, err = strconv.ParseUint(value, 10, 32)
+			cfg.ServerId = uint32(id)
+		case "loc":
+			if value == "Local" {
+				cfg.Loc = time.Local
+			} else {
+				cfg.Loc, err = time.LoadLocation(value)
+			}
+		case "charset":
+			if _, ok := charsetCollations[value]; !ok {
+				err = fmt.Errorf("unknown charset %s", value)
+			}
+			cfg.Charset = value
+		case "tls":
+			err = cfg.parseTLS(value)
+		default:
+			if cfg.Params == nil {
+				cfg.Params = map[string]string{}
+			}
+			cfg.Params[key] = value
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid DSN parameter %s: %s", key, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) parseTLS(value string) error {
+	cfg.tlsName = ""
+
+	switch strings.ToLower(value) {
+	case "false", "disabled", "0":
+		cfg.TLSMode = TLS_MODE_DISABLED
+	case "preferred":
+		cfg.TLSMode = TLS_MODE_PREFERRED
+	case "skip-verify", "required":
+		cfg.TLSMode = TLS_MODE_REQUIRED
+	case "verify-ca":
+		cfg.TLSMode = TLS_MODE_VERIFY_CA
+	case "true", "verify-identity", "1":
+		cfg.TLSMode = TLS_MODE_VERIFY_IDENTITY
+	default:
+		config, ok := getTLSConfig(value)
+		if !ok {
+			return fmt.Errorf("tls config %s is not registered", value)
+		}
+		cfg.TLSConfig = config
+		cfg.TLSMode = TLS_MODE_VERIFY_IDENTITY
+		if config.InsecureSkipVerify {
+			cfg.TLSMode = TLS_MODE_REQUIRED
+		}
+		cfg.tlsName = value
+	}
+
+	return nil
+}
+
+// FormatDSN is the reverse of ParseDSN
+func (cfg *Config) FormatDSN() string {
+	var dsn string
+
+	if cfg.User != "" || cfg.Passwd != "" {
+		dsn = cfg.User
+		if cfg.Passwd != "" {
+			dsn += ":" + cfg.Passwd
+		}
+		dsn += "@"
+	}
+
+	dsn += fmt.Sprintf("%s(%s)/%s", cfg.Net, cfg.Addr, cfg.DBName)
+
+	params := []string{}
+	if cfg.Timeout > 0 {
+		params = append(params, "timeout="+cfg.Timeout.String())
+	}
+	if cfg.ReadTimeout > 0 {
+		params = append(params, "readTimeout="+cfg.ReadTimeout.String())
+	}
+	if cfg.WriteTimeout > 0 {
+		params = append(params, "writeTimeout="+cfg.WriteTimeout.String())
+	}
+	if cfg.HeartbeatPeriod > 0 {
+		params = append(params, "heartbeatPeriod="+cfg.HeartbeatPeriod.String())
+	}
+	if cfg.HeartbeatMisses > 0 {
+		params = append(params, "heartbeatMisses="+strconv.Itoa(cfg.HeartbeatMisses))
+	}
+	if cfg.SemiSync {
+		params = append(params, "semiSync=true")
+	}
+	if cfg.ServerId > 0 {
+		params = append(params, "serverId="+strconv.FormatUint(uint64(cfg.ServerId), 10))
+	}
+	if cfg.Loc != nil {
+		params = append(params, "loc="+url.QueryEscape(cfg.Loc.String()))
+	}
+	if cfg.Charset != "" {
+		params = append(params, "charset="+cfg.Charset)
+	}
+	if tlsParam := cfg.tlsParam(); tlsParam != "" {
+		params = append(params, "tls="+tlsParam)
+	}
+
+	keys := make([]string, 0, len(cfg.Params))
+	for key := range cfg.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		params = append(params, key+"="+url.QueryEscape(cfg.Params[key]))
+	}
+
+	if len(params) > 0 {
+		dsn += "?" + strings.Join(params, "&")
+	}
+
+	return dsn
+}
+
+func (cfg *Config) tlsParam() string {
+	if cfg.tlsName != "" {
+		return cfg.tlsName
+	}
+
+	switch cfg.TLSMode {
+	case TLS_MODE_PREFERRED:
+		return "preferred"
+	case TLS_MODE_REQUIRED:
+		return "required"
+	case TLS_MODE_VERIFY_CA:
+		return "verify-ca"
+	case TLS_MODE_VERIFY_IDENTITY:
+		return "verify-identity"
+	}
+
+	return ""
+}
+
+func (cfg *Config) host() string {
+	if cfg.Net == "unix" {
+		return "localhost"
+	}
+
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return cfg.Addr
+	}
+	return host
+}
+
+func (cfg *Config) location() *time.Location {
+	if cfg == nil || cfg.Loc == nil {
+		return time.Local
+	}
+	return cfg.Loc
+}
+
+func (cfg *Config) collation() (byte, bool) {
+	collation, ok := charsetCollations[cfg.Charset]
+	return collation, ok
+}
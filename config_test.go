@@ -0,0 +1,163 @@
+package myreplication
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDSN(t *testing.T) {
+	customTLS := &tls.Config{ServerName: "mysql.example.com"}
+	if err := RegisterTLSConfig("custom", customTLS); err != nil {
+		t.Fatal("Register tls config fail", err)
+	}
+	defer DeregisterTLSConfig("custom")
+
+	type dsnTestCase struct {
+		dsn      string
+		expected *Config
+	}
+
+	testCases := []*dsnTestCase{
+		&dsnTestCase{
+			"repl:secret@tcp(10.0.0.1:3307)/",
+			&Config{User: "repl", Passwd: "secret", Net: "tcp", Addr: "10.0.0.1:3307", DBName: _DEFAULT_DB},
+		},
+		&dsnTestCase{
+			"repl@tcp(db.local)/information_schema?serverId=42&charset=utf8mb4&semiSync=true",
+			&Config{User: "repl", Net: "tcp", Addr: "db.local:3306", DBName: _DEFAULT_DB, ServerId: 42, Charset: "utf8mb4", SemiSync: true},
+		},
+		&dsnTestCase{
+			"repl:p@ss:w/rd@unix(/var/run/mysqld/mysqld.sock)/?timeout=5s&readTimeout=1m&writeTimeout=10s&heartbeatPeriod=30s&heartbeatMisses=3",
+			&Config{
+				User: "repl", Passwd: "p@ss:w/rd", Net: "unix", Addr: "/var/run/mysqld/mysqld.sock", DBName: _DEFAULT_DB,
+				Timeout: 5 * time.Second, ReadTimeout: time.Minute, WriteTimeout: 10 * time.Second,
+				HeartbeatPeriod: 30 * time.Second, HeartbeatMisses: 3,
+			},
+		},
+		&dsnTestCase{
+			"repl@tcp(127.0.0.1:3306)/?loc=UTC",
+			&Config{User: "repl", Net: "tcp", Addr: "127.0.0.1:3306", DBName: _DEFAULT_DB, Loc: time.UTC},
+		},
+		&dsnTestCase{
+			"repl@tcp(127.0.0.1:3306)/?loc=Local",
+			&Config{User: "repl", Net: "tcp", Addr: "127.0.0.1:3306", DBName: _DEFAULT_DB, Loc: time.Local},
+		},
+		&dsnTestCase{
+			"/",
+			&Config{Net: "tcp", Addr: "127.0.0.1:3306", DBName: _DEFAULT_DB},
+		},
+		&dsnTestCase{
+			"repl@tcp([::1]:3306)/?tls=skip-verify&parseTime=true",
+			&Config{User: "repl", Net: "tcp", Addr: "[::1]:3306", DBName: _DEFAULT_DB, TLSMode: TLS_MODE_REQUIRED, Params: map[string]string{"parseTime": "true"}},
+		},
+		&dsnTestCase{
+			"repl@tcp(127.0.0.1:3306)/?tls=verify-ca",
+			&Config{User: "repl", Net: "tcp", Addr: "127.0.0.1:3306", DBName: _DEFAULT_DB, TLSMode: TLS_MODE_VERIFY_CA},
+		},
+		&dsnTestCase{
+			"repl@tcp(127.0.0.1:3306)/?tls=custom",
+			&Config{User: "repl", Net: "tcp", Addr: "127.0.0.1:3306", DBName: _DEFAULT_DB, TLSMode: TLS_MODE_VERIFY_IDENTITY, TLSConfig: customTLS, tlsName: "custom"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		cfg, err := ParseDSN(testCase.dsn)
+
+		if err != nil {
+			t.Fatal("Parse DSN fail at test", i, err)
+		}
+
+		if !reflect.DeepEqual(testCase.expected, cfg) {
+			t.Fatal(
+				"Incorrect config at test", i,
+				"expected", testCase.expected,
+				"got", cfg,
+			)
+		}
+
+		formatted, err := ParseDSN(cfg.FormatDSN())
+		if err != nil {
+			t.Fatal("Parse formatted DSN fail at test", i, err)
+		}
+
+		if !reflect.DeepEqual(cfg, formatted) {
+			t.Fatal(
+				"Incorrect formatted DSN at test", i,
+				"expected", cfg,
+				"got", formatted,
+			)
+		}
+	}
+}
+
+func TestParseDSNError(t *testing.T) {
+	testCases := []string{
+		"repl@tcp(127.0.0.1:3306)",
+		"repl@tcp(127.0.0.1:3306/",
+		"repl@udp(127.0.0.1:3306)/",
+		"repl@tcp(127.0.0.1:3306)/?timeout=soon",
+		"repl@tcp(127.0.0.1:3306)/?serverId=-1",
+		"repl@tcp(127.0.0.1:3306)/?heartbeatMisses=many",
+		"repl@tcp(127.0.0.1:3306)/?semiSync=maybe",
+		"repl@tcp(127.0.0.1:3306)/?charset=klingon",
+		"repl@tcp(127.0.0.1:3306)/?loc=Mars%2FOlympus",
+		"repl@tcp(127.0.0.1:3306)/?tls=unknown",
+	}
+
+	for i, dsn := range testCases {
+		if _, err := ParseDSN(dsn); err == nil {
+			t.Fatal("Expected DSN error at test", i, dsn)
+		}
+	}
+}
+
+func TestConnectUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "myreplication")
+	if err != nil {
+		t.Fatal("Temp dir fail", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "mysqld.sock")
+
+	server := newStandInServer(t, tls.Certificate{}, false)
+	server.listener.Close()
+	if server.listener, err = net.Listen("unix", socket); err != nil {
+		t.Fatal("Listen fail", err)
+	}
+	defer server.close()
+
+	//unix socket is a secure transport, password is sent in clear text
+	server.pluginName = _AUTH_SHA256_PASSWORD
+	server.authenticate = func(session *standInSession, authData []byte) error {
+		if string(authData) != "secret\x00" {
+			return errStandInAccessDenied
+		}
+		return nil
+	}
+	server.start()
+
+	cfg, err := ParseDSN("repl:secret@unix(" + socket + ")/?charset=utf8mb4&readTimeout=5s")
+	if err != nil {
+		t.Fatal("Parse DSN fail", err)
+	}
+
+	connection := NewConnection()
+	if err = connection.Connect(cfg); err != nil {
+		t.Fatal("Connection fail", err)
+	}
+	defer connection.Close()
+
+	if username := <-server.username; username != "repl" {
+		t.Fatal(
+			"Incorrect username",
+			"expected", "repl",
+			"got", username,
+		)
+	}
+}
@@ -1,12 +1,17 @@
 package myreplication
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"net"
-	"runtime/debug"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type (
@@ -19,7 +24,19 @@ type (
 		masterPosition uint64
 		fileName       string
 
-		ctrDB *sql.DB
+		ctrDB   *sql.DB
+		config  *Config
+		netConn *timeoutConn
+
+		tlsMode       TLSMode
+		tlsConfig     *tls.Config
+		tlsConfigName string
+	}
+
+	// AuthError is a failure of the client side of the authentication, like an
+	// unsupported auth plugin, that a reconnect runs into again
+	AuthError struct {
+		Err error
 	}
 )
 
@@ -38,41 +55,140 @@ func (c *Connection) Connection() net.Conn {
 	return c.conn
 }
 
+// SetTLS must be called before ConnectAndAuth, config may be nil for the
+// modes that do not verify the server certificate
+func (c *Connection) SetTLS(mode TLSMode, config *tls.Config) {
+	c.tlsMode = mode
+	c.tlsConfig = config
+}
+
 func (c *Connection) ConnectAndAuth(host string, port int, username, password string) error {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", host, port))
+	cfg := NewConfig()
+	cfg.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	cfg.User = username
+	cfg.Passwd = password
+	cfg.TLSMode = c.tlsMode
+	cfg.TLSConfig = c.tlsConfig
+
+	return c.Connect(cfg)
+}
+
+func (c *Connection) Connect(cfg *Config) error {
+	return c.ConnectContext(context.Background(), cfg)
+}
+
+// ConnectContext stops dialing and the handshake when ctx is done
+func (c *Connection) ConnectContext(ctx context.Context, cfg *Config) error {
+	c.config = cfg
+	c.tlsMode = cfg.TLSMode
+	c.tlsConfig = cfg.TLSConfig
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, cfg.Net, cfg.Addr)
 
 	if err != nil {
 		return err
 	}
-	c.conn = conn
+	c.netConn = &timeoutConn{
+		Conn:         conn,
+		readTimeout:  cfg.ReadTimeout,
+		writeTimeout: cfg.WriteTimeout,
+	}
+	c.setConn(c.netConn)
+
+	stop := c.watchContext(ctx)
+	err = c.init(cfg)
+	stop()
+
+	if err != nil {
+		c.conn.Close()
+		return contextError(ctx, err)
+	}
+
+	if err = c.initCtrDB(cfg); err != nil {
+		c.Close()
+		return err
+	}
 
+	return nil
+}
+
+func (c *Connection) setConn(conn net.Conn) {
+	c.conn = conn
 	c.packReader = newPackReader(conn)
 	c.packWriter = newPackWriter(conn)
+}
 
-	if err = c.init(username, password); err != nil {
+func (c *Connection) initCtrDB(cfg *Config) error {
+	tlsParam, err := c.ctrDBTLSParam(cfg.host())
+	if err != nil {
 		return err
 	}
 
-	if err = c.initCtrDB(host, port, username, password, _DEFAULT_DB); err != nil {
+	if c.ctrDB, err = sql.Open("mysql", ctrDSN(cfg, tlsParam)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (c *Connection) initCtrDB(host string, port int, username, password, defaultDB string) error {
+// ctrDSN returns the DSN of the database/sql connection. go-sql-driver sends
+// the parameters it does not know as SET statements, so only the fields it
+// knows are taken from cfg.
+func ctrDSN(cfg *Config, tlsParam string) string {
+	ctrCfg := &Config{
+		User:         cfg.User,
+		Passwd:       cfg.Passwd,
+		Net:          cfg.Net,
+		Addr:         cfg.Addr,
+		DBName:       cfg.DBName,
+		Charset:      cfg.Charset,
+		Timeout:      cfg.Timeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		Loc:          cfg.location(),
+		Params: map[string]string{
+			"parseTime":         "True",
+			"interpolateParams": "true",
+		},
+	}
+
+	if tlsParam != "" {
+		ctrCfg.Params["tls"] = tlsParam
+	}
+
+	return ctrCfg.FormatDSN()
+}
 
-	var err error
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=True&loc=Local&interpolateParams=true",
-		username, password, host, port, defaultDB)
-	if c.ctrDB, err = sql.Open("mysql", dsn); err != nil {
+func (c *Connection) ctrDBTLSParam(host string) (string, error) {
+	switch c.tlsMode {
+	case TLS_MODE_DISABLED:
+		return "", nil
+	case TLS_MODE_PREFERRED:
+		return "preferred", nil
+	}
+
+	c.tlsConfigName = fmt.Sprintf("myreplication-%p", c)
+	err := mysql.RegisterTLSConfig(c.tlsConfigName, clientTLSConfig(c.tlsMode, c.tlsConfig, host))
+	if err != nil {
+		c.tlsConfigName = ""
+		return "", err
+	}
+
+	return c.tlsConfigName, nil
+}
+
+func (c *Connection) upgradeTLS(host string) error {
+	tlsConn := tls.Client(c.conn, clientTLSConfig(c.tlsMode, c.tlsConfig, host))
+	if err := tlsConn.Handshake(); err != nil {
 		return err
 	}
 
+	c.setConn(tlsConn)
 	return nil
 }
 
-func (c *Connection) init(username, password string) (err error) {
+func (c *Connection) init(cfg *Config) (err error) {
 	pack, err := c.packReader.readNextPack()
 	if err != nil {
 		return err
@@ -87,30 +203,113 @@ func (c *Connection) init(username, password string) (err error) {
 		return
 	}
 
+	if collation, ok := cfg.collation(); ok {
+		handshake.character_set = collation
+	}
+	handshake.unixSocket = cfg.Net == "unix"
+
+	sequence := byte(1)
+
+	if c.tlsMode != TLS_MODE_DISABLED {
+		if handshake.capabilities&_CLIENT_SSL == _CLIENT_SSL {
+			handshake.ssl = true
+			pack = handshake.writeSSLRequest()
+			pack.setSequence(sequence)
+			if err = c.packWriter.flush(pack); err != nil {
+				return
+			}
+			sequence++
+
+			if err = c.upgradeTLS(cfg.host()); err != nil {
+				return
+			}
+		} else if c.tlsMode != TLS_MODE_PREFERRED {
+			return TLS_NOT_SUPPORTED_ERR
+		}
+	}
+
 	//prepare and buff handshake auth response
-	pack = handshake.writeServer(username, password)
-	pack.setSequence(byte(1))
+	pack = handshake.writeServer(cfg.User, cfg.Passwd)
+	pack.setSequence(sequence)
 	err = c.packWriter.flush(pack)
 
 	if err != nil {
 		return
 	}
 
-	pack, err = c.packReader.readNextPack()
-	if err != nil {
-		return err
+	return c.authenticate(handshake.auth)
+}
+
+// authenticate reads the server answers until the auth exchange is finished,
+// following AuthSwitchRequest and AuthMoreData packets
+func (c *Connection) authenticate(auth *authenticator) error {
+	for {
+		pack, err := c.packReader.readNextPack()
+		if err != nil {
+			return err
+		}
+
+		if err = pack.isError(); err != nil {
+			return err
+		}
+
+		if len(pack.buff) == 0 {
+			return &AuthError{errors.New("empty auth response packet")}
+		}
+
+		var response []byte
+
+		switch pack.buff[0] {
+		case _MYSQL_OK:
+			return nil
+		case _AUTH_SWITCH_REQUEST:
+			if response, err = auth.switchPlugin(pack); err != nil {
+				return &AuthError{err}
+			}
+			if response == nil {
+				response = []byte{}
+			}
+		case _AUTH_MORE_DATA:
+			pack.ReadByte()
+			if response, err = auth.moreData(pack.Bytes()); err != nil {
+				return &AuthError{err}
+			}
+		default:
+			return &AuthError{fmt.Errorf("unexpected auth packet 0x%02x", pack.buff[0])}
+		}
+
+		if response == nil {
+			continue
+		}
+
+		answer := newPack()
+		answer.Write(response)
+		answer.setSequence(pack.getSequence() + 1)
+		if err = c.packWriter.flush(answer); err != nil {
+			return err
+		}
 	}
+}
 
-	return pack.isError()
+func (e *AuthError) Error() string {
+	return "authentication failed: " + e.Err.Error()
 }
 
 func (c *Connection) Close() {
-	println("Closed!")
-	debug.PrintStack()
 	if c.conn != nil {
 		c.conn.Close()
 	}
 
+	if c.ctrDB != nil {
+		c.ctrDB.Close()
+		c.ctrDB = nil
+	}
+
+	if c.tlsConfigName != "" {
+		mysql.DeregisterTLSConfig(c.tlsConfigName)
+		c.tlsConfigName = ""
+	}
+
 	c.conn = nil
 }
 
@@ -168,11 +367,30 @@ func (c *Connection) ChecksumCompatibility() (ok bool, err error) {
 	if len(_type) == 0 {
 		return
 	}
-	ok = true
+	//events carry no checksum with NONE, the slave still announces it understands them
+	ok = !strings.EqualFold(string(_type), "NONE")
 	_, err = c.query("set @master_binlog_checksum = @@global.binlog_checksum")
 	return
 }
 
+func (c *Connection) enableSemiSync() error {
+	rs, err := c.query("SHOW VARIABLES LIKE 'rpl_semi_sync_master_enabled'")
+	if err != nil {
+		return err
+	}
+
+	if _, err = rs.nextRow(); err != nil {
+		if err == EOF_ERR {
+			return SEMI_SYNC_NOT_SUPPORTED_ERR
+		}
+		return err
+	}
+	rs.nextRow()
+
+	_, err = c.query("SET @rpl_semi_sync_slave = 1")
+	return err
+}
+
 func (c *Connection) initDb(schemaName string) error {
 	q := &initDb{}
 	pack := q.writeServer(schemaName)
@@ -251,12 +469,84 @@ func (c *Connection) fieldList(db, table string) (*resultSet, error) {
 	return rs, nil
 }
 
+// StartBinlogDump uses the server id from the Config when serverId is 0
 func (c *Connection) StartBinlogDump(position uint32, fileName string, serverId uint32) (el *EventLog, err error) {
+	return c.StartBinlogDumpContext(context.Background(), position, fileName, serverId)
+}
+
+// StartBinlogDumpContext aborts the dump request when ctx is done,
+// the connection is not usable after that and must be closed
+func (c *Connection) StartBinlogDumpContext(ctx context.Context, position uint32, fileName string, serverId uint32) (el *EventLog, err error) {
+	startBinLog := &binlogDump{}
+	el, err = c.startBinlogDump(ctx, serverId, func(serverId uint32) *pack {
+		return startBinLog.writeServer(position, fileName, serverId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//the master confirms it with the artificial rotate event
+	el.lastRotateFileName = []byte(fileName)
+	el.lastRotatePosition = position
+	return el, nil
+}
+
+// StartBinlogDumpGTID starts replication after the transactions of gtidSet,
+// the EventLog adds the transactions it receives to its own copy of the set
+func (c *Connection) StartBinlogDumpGTID(gtidSet *GTIDSet, serverId uint32) (el *EventLog, err error) {
+	return c.StartBinlogDumpGTIDContext(context.Background(), gtidSet, serverId)
+}
+
+func (c *Connection) StartBinlogDumpGTIDContext(ctx context.Context, gtidSet *GTIDSet, serverId uint32) (el *EventLog, err error) {
+	startBinLog := &binlogDump{}
+	el, err = c.startBinlogDump(ctx, serverId, func(serverId uint32) *pack {
+		return startBinLog.writeServerGTID(gtidSet, serverId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	el.gtidSet = gtidSet.Clone()
+	return el, nil
+}
+
+func (c *Connection) startBinlogDump(ctx context.Context, serverId uint32, dump func(serverId uint32) *pack) (el *EventLog, err error) {
+	stop := c.watchContext(ctx)
+	defer func() {
+		stop()
+		err = contextError(ctx, err)
+	}()
+
+	if serverId == 0 && c.config != nil {
+		serverId = c.config.ServerId
+	}
+
 	ok, err := c.ChecksumCompatibility()
 	if err != nil {
 		return
 	}
 
+	var heartbeatPeriod, watchdog time.Duration
+	if c.config != nil {
+		heartbeatPeriod = c.config.HeartbeatPeriod
+		watchdog = heartbeatPeriod * time.Duration(c.config.HeartbeatMisses)
+	}
+
+	semiSync := c.config != nil && c.config.SemiSync
+	if semiSync {
+		if err = c.enableSemiSync(); err != nil {
+			return
+		}
+	}
+
+	if heartbeatPeriod > 0 {
+		//nanoseconds
+		_, err = c.query("SET @master_heartbeat_period = " + strconv.FormatInt(heartbeatPeriod.Nanoseconds(), 10))
+		if err != nil {
+			return
+		}
+	}
+
 	register := &registerSlave{}
 	pack := register.writeServer(serverId)
 	err = c.packWriter.flush(pack)
@@ -276,9 +566,7 @@ func (c *Connection) StartBinlogDump(position uint32, fileName string, serverId
 		return nil, err
 	}
 
-	startBinLog := &binlogDump{}
-	pack = startBinLog.writeServer(position, fileName, serverId)
-	err = c.packWriter.flush(pack)
+	err = c.packWriter.flush(dump(serverId))
 	if err != nil {
 		return nil, err
 	}
@@ -290,6 +578,12 @@ func (c *Connection) StartBinlogDump(position uint32, fileName string, serverId
 	}
 
 	el = newEventLog(c, additionalLength)
+	el.semiSyncEnabled = semiSync
+
+	if watchdog > 0 && c.netConn != nil {
+		el.watchdog = watchdog
+		c.netConn.setIdleTimeout(watchdog)
+	}
 
 	return el, nil
 }
@@ -302,9 +596,11 @@ func (c *Connection) getSchemaColumns(schema string, table string) ([]*SchemaCol
 			COLUMN_NAME, COLLATION_NAME, CHARACTER_SET_NAME,
 			COLUMN_COMMENT, COLUMN_TYPE, COLUMN_KEY
 		FROM
-			COLUMNS
+			information_schema.COLUMNS
 		WHERE
-			TABLE_SCHEMA = ? AND TABLE_NAME = ?`, schema, table); err != nil {
+			TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY
+			ORDINAL_POSITION`, schema, table); err != nil {
 		return nil, err
 	}
 
@@ -327,3 +623,129 @@ func (c *Connection) getSchemaColumns(schema string, table string) ([]*SchemaCol
 
 	return cols, nil
 }
+
+// watchContext applies the ctx deadline to the connection and interrupts
+// blocked reads and writes on cancel, the returned func must be called when done
+func (c *Connection) watchContext(ctx context.Context) func() {
+	if c.netConn == nil {
+		return func() {}
+	}
+	return c.netConn.watch(ctx)
+}
+
+func contextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	//the connection deadline may expire a moment before the context timer
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		<-ctx.Done()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+var (
+	aLongTimeAgo = time.Unix(1, 0)
+)
+
+// timeoutConn applies the configured read and write timeouts and the
+// deadline of the watched context to every call
+type timeoutConn struct {
+	net.Conn
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	// limits the wait for the binlog events, see Config.HeartbeatMisses
+	idleTimeout time.Duration
+
+	lock        sync.Mutex
+	ctxDeadline time.Time
+	cancelled   bool
+}
+
+func (c *timeoutConn) setIdleTimeout(timeout time.Duration) {
+	c.lock.Lock()
+	c.idleTimeout = timeout
+	c.lock.Unlock()
+}
+
+func (c *timeoutConn) watch(ctx context.Context) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	deadline, _ := ctx.Deadline()
+	c.lock.Lock()
+	c.ctxDeadline = deadline
+	c.lock.Unlock()
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			c.lock.Lock()
+			c.cancelled = true
+			c.Conn.SetDeadline(aLongTimeAgo)
+			c.lock.Unlock()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+		c.lock.Lock()
+		c.ctxDeadline = time.Time{}
+		c.cancelled = false
+		c.Conn.SetDeadline(time.Time{})
+		c.lock.Unlock()
+	}
+}
+
+func (c *timeoutConn) deadline(timeout time.Duration) time.Time {
+	if c.cancelled {
+		return aLongTimeAgo
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if !c.ctxDeadline.IsZero() && (deadline.IsZero() || c.ctxDeadline.Before(deadline)) {
+		deadline = c.ctxDeadline
+	}
+	return deadline
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	c.lock.Lock()
+	timeout := c.readTimeout
+	if c.idleTimeout > 0 && (timeout == 0 || c.idleTimeout < timeout) {
+		timeout = c.idleTimeout
+	}
+	err := c.Conn.SetReadDeadline(c.deadline(timeout))
+	c.lock.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	c.lock.Lock()
+	err := c.Conn.SetWriteDeadline(c.deadline(c.writeTimeout))
+	c.lock.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,260 @@
+package myreplication
+
+import (
+	"context"
+	"github.com/go-sql-driver/mysql"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPipeConnection() (*Connection, net.Conn) {
+	client, server := net.Pipe()
+
+	connection := NewConnection()
+	connection.netConn = &timeoutConn{Conn: client}
+	connection.setConn(connection.netConn)
+
+	return connection, server
+}
+
+func TestConnectContextDeadline(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen fail", err)
+	}
+	defer listener.Close()
+
+	//accept and never send the handshake
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 1))
+	}()
+
+	cfg := NewConfig()
+	cfg.Addr = listener.Addr().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err = NewConnection().ConnectContext(ctx, cfg)
+
+	if err != context.DeadlineExceeded {
+		t.Fatal(
+			"Incorrect connect error",
+			"expected", context.DeadlineExceeded,
+			"got", err,
+		)
+	}
+
+	if time.Since(started) > time.Second {
+		t.Fatal("Connect is not interrupted by the deadline")
+	}
+}
+
+func TestGetEventContext(t *testing.T) {
+	type contextTestCase struct {
+		ctx           func() (context.Context, context.CancelFunc)
+		expectedError error
+	}
+
+	testCases := []*contextTestCase{
+		&contextTestCase{
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			context.DeadlineExceeded,
+		},
+		&contextTestCase{
+			func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			context.Canceled,
+		},
+	}
+
+	for i, testCase := range testCases {
+		connection, server := newPipeConnection()
+		el := newEventLog(connection, 0)
+
+		ctx, cancel := testCase.ctx()
+		_, err := el.GetEventContext(ctx)
+		cancel()
+
+		if err != testCase.expectedError {
+			t.Fatal(
+				"Incorrect event error at test", i,
+				"expected", testCase.expectedError,
+				"got", err,
+			)
+		}
+
+		server.Close()
+	}
+}
+
+func TestGetEventContextResetDeadline(t *testing.T) {
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+	xidEvent := []byte{
+		0x1c, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x01, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	intVarEvent := []byte{
+		0x1d, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x01, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x00, 0x00, 0x1c, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x02, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	go func() {
+		server.Write(xidEvent)
+		server.Write(intVarEvent)
+	}()
+
+	event, err := el.GetEventContext(ctx)
+	cancel()
+
+	if err != nil {
+		t.Fatal("Get event fail", err)
+	}
+
+	intVar, ok := event.(*IntVarEvent)
+	if !ok || intVar.GetValue() != 7 {
+		t.Fatal(
+			"Incorrect event",
+			"expected", uint64(7),
+			"got", event,
+		)
+	}
+
+	//cancelled context must not leak into the next read
+	go server.Write(intVarEvent)
+	if _, err = el.GetEvent(); err != nil {
+		t.Fatal("Get event after context fail", err)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	end := master.appendQuery("CREATE TABLE t (id int)")
+	master.heartbeats = 1
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+	cfg.HeartbeatPeriod = 20 * time.Millisecond
+	cfg.HeartbeatMisses = 3
+
+	connection := NewConnection()
+	if err := connection.Connect(cfg); err != nil {
+		t.Fatal("Connection fail", err)
+	}
+	defer connection.Close()
+
+	el, err := connection.StartBinlogDump(4, master.fileName, 2)
+	if err != nil {
+		t.Fatal("Start binlog dump fail", err)
+	}
+	el.SetHeartbeatEvents(true)
+
+	expectedQuery := "SET @master_heartbeat_period = 20000000"
+	found := false
+	for len(master.queries) > 0 {
+		if <-master.queries == expectedQuery {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("Heartbeat period is not set", "expected", expectedQuery)
+	}
+
+	if _, err = el.GetEvent(); err != nil {
+		t.Fatal("Get event fail", err)
+	}
+
+	event, err := el.GetEvent()
+	heartbeat, ok := event.(*HeartBeatEvent)
+	if err != nil || !ok || heartbeat.GetLogFileName() != master.fileName || heartbeat.GetPosition() != end {
+		t.Fatal(
+			"Incorrect heartbeat",
+			"expected", master.fileName, end,
+			"got", event, err,
+		)
+	}
+
+	//master is silent now
+	started := time.Now()
+	if _, err = el.GetEvent(); err != HEARTBEAT_LOST_ERR {
+		t.Fatal(
+			"Incorrect watchdog error",
+			"expected", HEARTBEAT_LOST_ERR,
+			"got", err,
+		)
+	}
+
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Fatal("Incorrect watchdog period", elapsed)
+	}
+}
+
+func TestCtrDSN(t *testing.T) {
+	cfg := &Config{
+		User:            "repl",
+		Passwd:          "secret",
+		Net:             "tcp",
+		Addr:            "127.0.0.1:3306",
+		DBName:          _DEFAULT_DB,
+		Timeout:         time.Second,
+		HeartbeatPeriod: 5 * time.Second,
+		HeartbeatMisses: 3,
+		ServerId:        1001,
+		SemiSync:        true,
+		Loc:             time.UTC,
+		TLSMode:         TLS_MODE_REQUIRED,
+		Params:          map[string]string{"unknown": "1"},
+	}
+
+	dsn := ctrDSN(cfg, "preferred")
+	if strings.Count(dsn, "loc=") != 1 {
+		t.Fatal("Incorrect control DSN loc", "expected", "one", "got", dsn)
+	}
+
+	ctrCfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal("Parse control DSN fail", err)
+	}
+
+	//the parameters go-sql-driver does not know would be sent as SET statements
+	for _, param := range []string{"heartbeatPeriod", "heartbeatMisses", "serverId", "semiSync", "unknown"} {
+		if value, ok := ctrCfg.Params[param]; ok {
+			t.Fatal("Incorrect control DSN parameter", param, "expected", "none", "got", value)
+		}
+	}
+
+	if ctrCfg.User != cfg.User || ctrCfg.Passwd != cfg.Passwd || ctrCfg.Addr != cfg.Addr || ctrCfg.Timeout != cfg.Timeout {
+		t.Fatal("Incorrect control DSN", "expected", cfg, "got", ctrCfg)
+	}
+
+	if ctrCfg.Loc != time.UTC || !ctrCfg.ParseTime {
+		t.Fatal("Incorrect control DSN time parsing", "expected", time.UTC, "got", ctrCfg.Loc, ctrCfg.ParseTime)
+	}
+
+	if ctrCfg.TLSConfig != "preferred" {
+		t.Fatal("Incorrect control DSN TLS", "expected", "preferred", "got", ctrCfg.TLSConfig)
+	}
+}
@@ -54,6 +54,7 @@ const (
 	MYSQL_TYPE_TIMESTAMP2  = 0x11
 	MYSQL_TYPE_DATETIME2   = 0x12
 	MYSQL_TYPE_TIME2       = 0x13
+	MYSQL_TYPE_JSON        = 0xf5
 	MYSQL_TYPE_NEWDECIMAL  = 0xf6
 	MYSQL_TYPE_ENUM        = 0xf7
 	MYSQL_TYPE_SET         = 0xf8
@@ -69,42 +70,64 @@ const (
 	_MYSQL_OK  = 0x00
 	_MYSQL_ERR = 0xFF
 
-	_UNKNOWN_EVENT            = 0x00
-	_START_EVENT_V3           = 0x01
-	_QUERY_EVENT              = 0x02
-	_STOP_EVENT               = 0x03
-	_ROTATE_EVENT             = 0x04
-	_INTVAR_EVENT             = 0x05
-	_LOAD_EVENT               = 0x06
-	_SLAVE_EVENT              = 0x07
-	_CREATE_FILE_EVENT        = 0x08
-	_APPEND_BLOCK_EVENT       = 0x09
-	_EXEC_LOAD_EVENT          = 0x0a
-	_DELETE_FILE_EVENT        = 0x0b
-	_NEW_LOAD_EVENT           = 0x0c
-	_RAND_EVENT               = 0x0d
-	_USER_VAR_EVENT           = 0x0e
-	_FORMAT_DESCRIPTION_EVENT = 0x0f
-	_XID_EVENT                = 0x10
-	_BEGIN_LOAD_QUERY_EVENT   = 0x11
-	_EXECUTE_LOAD_QUERY_EVENT = 0x12
-	_TABLE_MAP_EVENT          = 0x13
-	_WRITE_ROWS_EVENTv0       = 0x14
-	_UPDATE_ROWS_EVENTv0      = 0x15
-	_DELETE_ROWS_EVENTv0      = 0x16
-	_WRITE_ROWS_EVENTv1       = 0x17
-	_UPDATE_ROWS_EVENTv1      = 0x18
-	_DELETE_ROWS_EVENTv1      = 0x19
-	_INCIDENT_EVENT           = 0x1a
-	_HEARTBEAT_EVENT          = 0x1b
-	_IGNORABLE_EVENT          = 0x1c
-	_ROWS_QUERY_EVENT         = 0x1d
-	_WRITE_ROWS_EVENTv2       = 0x1e
-	_UPDATE_ROWS_EVENTv2      = 0x1f
-	_DELETE_ROWS_EVENTv2      = 0x20
-	_GTID_EVENT               = 0x21
-	_ANONYMOUS_GTID_EVENT     = 0x22
-	_PREVIOUS_GTIDS_EVENT     = 0x23
+	_UNKNOWN_EVENT             = 0x00
+	_START_EVENT_V3            = 0x01
+	_QUERY_EVENT               = 0x02
+	_STOP_EVENT                = 0x03
+	_ROTATE_EVENT              = 0x04
+	_INTVAR_EVENT              = 0x05
+	_LOAD_EVENT                = 0x06
+	_SLAVE_EVENT               = 0x07
+	_CREATE_FILE_EVENT         = 0x08
+	_APPEND_BLOCK_EVENT        = 0x09
+	_EXEC_LOAD_EVENT           = 0x0a
+	_DELETE_FILE_EVENT         = 0x0b
+	_NEW_LOAD_EVENT            = 0x0c
+	_RAND_EVENT                = 0x0d
+	_USER_VAR_EVENT            = 0x0e
+	_FORMAT_DESCRIPTION_EVENT  = 0x0f
+	_XID_EVENT                 = 0x10
+	_BEGIN_LOAD_QUERY_EVENT    = 0x11
+	_EXECUTE_LOAD_QUERY_EVENT  = 0x12
+	_TABLE_MAP_EVENT           = 0x13
+	_WRITE_ROWS_EVENTv0        = 0x14
+	_UPDATE_ROWS_EVENTv0       = 0x15
+	_DELETE_ROWS_EVENTv0       = 0x16
+	_WRITE_ROWS_EVENTv1        = 0x17
+	_UPDATE_ROWS_EVENTv1       = 0x18
+	_DELETE_ROWS_EVENTv1       = 0x19
+	_INCIDENT_EVENT            = 0x1a
+	_HEARTBEAT_EVENT           = 0x1b
+	_IGNORABLE_EVENT           = 0x1c
+	_ROWS_QUERY_EVENT          = 0x1d
+	_WRITE_ROWS_EVENTv2        = 0x1e
+	_UPDATE_ROWS_EVENTv2       = 0x1f
+	_DELETE_ROWS_EVENTv2       = 0x20
+	_GTID_EVENT                = 0x21
+	_ANONYMOUS_GTID_EVENT      = 0x22
+	_PREVIOUS_GTIDS_EVENT      = 0x23
+	_PARTIAL_UPDATE_ROWS_EVENT = 0x27
+
+	_LOG_EVENT_ARTIFICIAL_F = 0x20
+
+	//value options of the after image of PARTIAL_UPDATE_ROWS_EVENT
+	_PARTIAL_JSON_UPDATES = 0x01
+
+	//TABLE_MAP_EVENT optional metadata field types
+	_TABLE_MAP_SIGNEDNESS                   = 0x01
+	_TABLE_MAP_DEFAULT_CHARSET              = 0x02
+	_TABLE_MAP_COLUMN_CHARSET               = 0x03
+	_TABLE_MAP_COLUMN_NAME                  = 0x04
+	_TABLE_MAP_SET_STR_VALUE                = 0x05
+	_TABLE_MAP_ENUM_STR_VALUE               = 0x06
+	_TABLE_MAP_GEOMETRY_TYPE                = 0x07
+	_TABLE_MAP_SIMPLE_PRIMARY_KEY           = 0x08
+	_TABLE_MAP_PRIMARY_KEY_WITH_PREFIX      = 0x09
+	_TABLE_MAP_ENUM_AND_SET_DEFAULT_CHARSET = 0x0a
+	_TABLE_MAP_ENUM_AND_SET_COLUMN_CHARSET  = 0x0b
+
+	_LOGICAL_TIMESTAMP_TYPECODE    = 0x02
+	_ENCODED_COMMIT_TIMESTAMP_FLAG = 1 << 55
 
 	_FORMAT_DESCRIPTION_LENGTH_QUERY_POSITION    = 1
 	_FORMAT_DESCRIPTION_LENGTH_DELETEV1_POSITION = 22
@@ -1,6 +1,15 @@
 package myreplication
 
-import ()
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
 
 type (
 	EventLog struct {
@@ -15,6 +24,59 @@ type (
 		tableMap                      map[uint64]*Table
 		lastTableMapEvent             *TableMapEvent
 		additionalLength              int
+
+		inTransaction bool
+		safeFileName  string
+		safePosition  uint32
+
+		gtidSet     *GTIDSet
+		pendingGTID *GtidEvent
+
+		checksumAlg  byte
+		skipChecksum bool
+
+		heartbeatEvents bool
+		watchdog        time.Duration
+
+		semiSyncEnabled bool
+		ackLock         sync.Mutex
+		ackRequested    bool
+		ackFileName     string
+		ackPosition     uint32
+
+		transaction         *Transaction
+		transactionLimit    int
+		transactionOverflow TransactionOverflow
+
+		tableFilter *TableFilter
+	}
+
+	// Event is implemented by every event GetEvent returns
+	Event interface {
+		Header() EventHeader
+		// GetStartPosition returns where the event begins in the binlog of the master
+		GetStartPosition() Position
+		// GetEndPosition returns where the next event begins, a dump restarted
+		// from it continues after the event
+		GetEndPosition() Position
+	}
+
+	// Position is a place in the binlog of the master
+	Position struct {
+		File string
+		Pos  uint32
+	}
+
+	// EventHeader is the common header of the binlog events, NextPosition is
+	// the end of the event in the LogFileName file
+	EventHeader struct {
+		Timestamp    uint32
+		EventType    byte
+		ServerId     uint32
+		LogFileName  string
+		NextPosition uint32
+		EventSize    uint32
+		Flags        uint16
 	}
 
 	eventLogHeader struct {
@@ -24,6 +86,8 @@ type (
 		EventSize    uint32
 		NextPosition uint32
 		Flags        uint16
+		start        Position
+		end          Position
 	}
 
 	logRotateEvent struct {
@@ -116,7 +180,8 @@ type (
 	}
 
 	binLogEvent interface {
-		read(*pack)
+		Event
+		read(*pack) error
 	}
 
 	AppendBlockEvent struct {
@@ -135,11 +200,52 @@ type (
 		*unknownEvent
 	}
 
+	GtidEvent struct {
+		*eventLogHeader
+		commitFlag               byte
+		sid                      [16]byte
+		gno                      int64
+		lastCommitted            int64
+		sequenceNumber           int64
+		immediateCommitTimestamp uint64
+		originalCommitTimestamp  uint64
+		transactionLength        uint64
+	}
+
+	PreviousGtidsEvent struct {
+		*eventLogHeader
+		gtidSet *GTIDSet
+	}
+
 	HeartBeatEvent struct {
 		*unknownEvent
+		logFileName string
 	}
 )
 
+var (
+	HEARTBEAT_LOST_ERR  = errors.New("no binlog event or heartbeat during the heartbeat watchdog period")
+	EVENT_TOO_SHORT_ERR = errors.New("event data is shorter than its structure")
+)
+
+type (
+	// ParseError is returned for an event that can not be decoded, the stream
+	// can not continue after it
+	ParseError struct {
+		FileName  string
+		Position  uint32
+		EventType byte
+		Err       error
+	}
+)
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf(
+		"can not parse binlog event 0x%02x at %s:%d: %s",
+		e.EventType, e.FileName, e.Position, e.Err.Error(),
+	)
+}
+
 func (event *RandEvent) GetSeed1() uint64 {
 	return event.seed1
 }
@@ -148,19 +254,150 @@ func (event *RandEvent) GetSeed2() uint64 {
 	return event.seed2
 }
 
-func (event *RandEvent) read(pack *pack) {
-	pack.readUint64(&event.seed1)
-	pack.readUint64(&event.seed2)
+func (event *RandEvent) read(pack *pack) error {
+	if err := pack.readUint64(&event.seed1); err != nil {
+		return err
+	}
+	return pack.readUint64(&event.seed2)
 }
 
-func (event *IncidentEvent) read(pack *pack) {
-	pack.readUint16(&event.Type)
-	length, _ := pack.ReadByte()
+func (event *IncidentEvent) read(pack *pack) error {
+	if err := pack.readUint16(&event.Type); err != nil {
+		return err
+	}
+	length, err := pack.ReadByte()
+	if err != nil {
+		return err
+	}
 	event.Message = string(pack.Next(int(length)))
+	return nil
+}
+
+func (event *unknownEvent) read(pack *pack) error {
+	return nil
 }
 
-func (event *unknownEvent) read(pack *pack) {
+// GetLogFileName returns the binlog file the master is sending
+func (event *HeartBeatEvent) GetLogFileName() string {
+	return event.logFileName
+}
+
+// GetPosition returns the master position in the binlog file, the end of
+// the last event sent
+func (event *HeartBeatEvent) GetPosition() uint32 {
+	return event.NextPosition
+}
+
+func (event *HeartBeatEvent) read(pack *pack) error {
+	event.logFileName = string(pack.Bytes())
+	return nil
+}
 
+// GetSID returns the uuid of the server the transaction originates from
+func (event *GtidEvent) GetSID() string {
+	return formatSid(event.sid)
+}
+
+func (event *GtidEvent) GetGNO() int64 {
+	return event.gno
+}
+
+// GetGTID returns the gtid in the sid:gno form, empty for an anonymous transaction
+func (event *GtidEvent) GetGTID() string {
+	if event.IsAnonymous() {
+		return ""
+	}
+	return event.GetSID() + ":" + strconv.FormatInt(event.gno, 10)
+}
+
+// IsAnonymous is true for ANONYMOUS_GTID_EVENT written when gtid_mode is off
+func (event *GtidEvent) IsAnonymous() bool {
+	return event.EventType == _ANONYMOUS_GTID_EVENT
+}
+
+func (event *GtidEvent) GetCommitFlag() byte {
+	return event.commitFlag
+}
+
+// GetLastCommitted and GetSequenceNumber are the logical timestamps used by
+// the multi threaded slave, 0 before MySQL 5.7
+func (event *GtidEvent) GetLastCommitted() int64 {
+	return event.lastCommitted
+}
+
+func (event *GtidEvent) GetSequenceNumber() int64 {
+	return event.sequenceNumber
+}
+
+// GetImmediateCommitTimestamp returns microseconds since epoch of the commit
+// on the server that wrote the binlog, 0 before MySQL 8.0
+func (event *GtidEvent) GetImmediateCommitTimestamp() uint64 {
+	return event.immediateCommitTimestamp
+}
+
+// GetOriginalCommitTimestamp returns microseconds since epoch of the commit
+// on the server the transaction originates from
+func (event *GtidEvent) GetOriginalCommitTimestamp() uint64 {
+	return event.originalCommitTimestamp
+}
+
+// GetTransactionLength returns the size in bytes of the transaction events
+// including this one, 0 before MySQL 8.0
+func (event *GtidEvent) GetTransactionLength() uint64 {
+	return event.transactionLength
+}
+
+func (event *GtidEvent) read(pack *pack) error {
+	if pack.Len() < 1+len(event.sid)+8 {
+		return EVENT_TOO_SHORT_ERR
+	}
+	event.commitFlag, _ = pack.ReadByte()
+	copy(event.sid[:], pack.Next(len(event.sid)))
+	var gno uint64
+	pack.readUint64(&gno)
+	event.gno = int64(gno)
+
+	//logical timestamps, MySQL 5.7
+	if pack.Len() < 17 {
+		return nil
+	}
+	if typeCode, _ := pack.ReadByte(); typeCode != _LOGICAL_TIMESTAMP_TYPECODE {
+		return nil
+	}
+	var lastCommitted, sequenceNumber uint64
+	pack.readUint64(&lastCommitted)
+	pack.readUint64(&sequenceNumber)
+	event.lastCommitted = int64(lastCommitted)
+	event.sequenceNumber = int64(sequenceNumber)
+
+	//commit timestamps, MySQL 8.0
+	if pack.Len() < 7 {
+		return nil
+	}
+	readFixByteUint64(pack.Next(7), &event.immediateCommitTimestamp)
+	event.originalCommitTimestamp = event.immediateCommitTimestamp
+	if event.immediateCommitTimestamp&_ENCODED_COMMIT_TIMESTAMP_FLAG != 0 {
+		event.immediateCommitTimestamp &^= _ENCODED_COMMIT_TIMESTAMP_FLAG
+		if pack.Len() < 7 {
+			return EVENT_TOO_SHORT_ERR
+		}
+		readFixByteUint64(pack.Next(7), &event.originalCommitTimestamp)
+	}
+
+	if pack.Len() == 0 {
+		return nil
+	}
+	var null bool
+	return pack.readIntLengthOrNil(&event.transactionLength, &null)
+}
+
+func (event *PreviousGtidsEvent) GetGTIDSet() *GTIDSet {
+	return event.gtidSet
+}
+
+func (event *PreviousGtidsEvent) read(pack *pack) (err error) {
+	event.gtidSet, err = decodeGTIDSet(pack.Bytes())
+	return
 }
 
 func (event *UserVarEvent) GetName() string {
@@ -183,22 +420,30 @@ func (event *UserVarEvent) GetValue() string {
 	return event.value
 }
 
-func (event *UserVarEvent) read(pack *pack) {
+func (event *UserVarEvent) read(pack *pack) error {
 	var nameLength uint32
-	pack.readUint32(&nameLength)
+	if err := pack.readUint32(&nameLength); err != nil {
+		return err
+	}
 	event.name = string(pack.Next(int(nameLength)))
-	isNull, _ := pack.ReadByte()
+	isNull, err := pack.ReadByte()
+	if err != nil {
+		return err
+	}
 	event.isNil = isNull == 1
 	if event.isNil {
-		return
+		return nil
 	}
 
 	event._type, _ = pack.ReadByte()
 	pack.readUint32(&event.charset)
 	var length uint32
-	pack.readUint32(&length)
+	if err = pack.readUint32(&length); err != nil {
+		return err
+	}
 	event.value = string(pack.Next(int(length)))
 	event.flags, _ = pack.ReadByte()
+	return nil
 }
 
 func (event *ExecuteLoadQueryEvent) GetSchema() string {
@@ -217,7 +462,7 @@ func (event *ExecuteLoadQueryEvent) GetErrorCode() uint16 {
 	return event.errorCode
 }
 
-func (event *ExecuteLoadQueryEvent) read(pack *pack) {
+func (event *ExecuteLoadQueryEvent) read(pack *pack) error {
 	pack.readUint32(&event.slaveProxyId)
 	pack.readUint32(&event.executionTime)
 
@@ -236,22 +481,26 @@ func (event *ExecuteLoadQueryEvent) read(pack *pack) {
 	event.statusVars = pack.Next(int(statusVarsLength))
 	event.schema = string(pack.Next(int(schemaLength)))
 
-	splitter, _ := pack.ReadByte()
+	splitter, err := pack.ReadByte()
 
-	if splitter != 0 {
-		panic("Incorrect binlog EXECUTE_LOAD_QUERY_EVENT structure")
+	if err != nil || splitter != 0 {
+		return errors.New("incorrect EXECUTE_LOAD_QUERY_EVENT structure")
 	}
 
 	event.query = string(pack.Bytes())
+	return nil
 }
 
 func (event *BeginLoadQueryEvent) GetData() string {
 	return event.blockData
 }
 
-func (event *BeginLoadQueryEvent) read(pack *pack) {
-	pack.readUint32(&event.fileId)
+func (event *BeginLoadQueryEvent) read(pack *pack) error {
+	if err := pack.readUint32(&event.fileId); err != nil {
+		return err
+	}
 	event.blockData = string(pack.Bytes())
+	return nil
 }
 
 func (event *IntVarEvent) GetValue() uint64 {
@@ -262,13 +511,15 @@ func (event *IntVarEvent) GetType() byte {
 	return event._type
 }
 
-func (event *IntVarEvent) read(pack *pack) {
-	event._type, _ = pack.ReadByte()
-	pack.readUint64(&event.value)
+func (event *IntVarEvent) read(pack *pack) (err error) {
+	if event._type, err = pack.ReadByte(); err != nil {
+		return
+	}
+	return pack.readUint64(&event.value)
 }
 
-func (event *XidEvent) read(pack *pack) {
-	pack.readUint64(&event.TransactionId)
+func (event *XidEvent) read(pack *pack) error {
+	return pack.readUint64(&event.TransactionId)
 }
 
 func (event *QueryEvent) GetQuery() string {
@@ -287,7 +538,7 @@ func (event *QueryEvent) GetSchema() string {
 	return event.schema
 }
 
-func (event *QueryEvent) read(pack *pack) {
+func (event *QueryEvent) read(pack *pack) error {
 	pack.readUint32(&event.slaveProxyId)
 	pack.readUint32(&event.executionTime)
 
@@ -302,37 +553,53 @@ func (event *QueryEvent) read(pack *pack) {
 	}
 
 	event.schema = string(pack.Next(int(schemaLength)))
-	splitter, _ := pack.ReadByte()
+	splitter, err := pack.ReadByte()
 
-	if splitter != 0 {
-		panic("Incorrect binlog QUERY_EVENT structure")
+	if err != nil || splitter != 0 {
+		return errors.New("incorrect QUERY_EVENT structure")
 	}
 
 	event.query = string(pack.Bytes())
+	return nil
 }
 
-func (event *logRotateEvent) read(pack *pack) {
-	pack.readUint64(&event.position)
+func (event *logRotateEvent) read(pack *pack) error {
+	if err := pack.readUint64(&event.position); err != nil {
+		return err
+	}
 	event.binlogFileName = pack.Next(pack.Len())
+	return nil
 }
 
-func (event *formatDescriptionEvent) read(pack *pack) {
+func (event *formatDescriptionEvent) read(pack *pack) error {
 	pack.readUint16(&event.binlogVersion)
 	event.mysqlServerVersion = pack.Next(50)
 	pack.readUint32(&event.createTimestamp)
-	length, _ := pack.ReadByte()
+	length, err := pack.ReadByte()
+	if err != nil {
+		return err
+	}
 	event.eventTypeHeaderLengths = pack.Next(int(length))
+	if len(event.eventTypeHeaderLengths) <= _FORMAT_DESCRIPTION_LENGTH_QUERY_POSITION {
+		return EVENT_TOO_SHORT_ERR
+	}
+	return nil
 }
 
-func (event *startEventV3Event) read(pack *pack) {
+func (event *startEventV3Event) read(pack *pack) error {
 	pack.readUint16(&event.binlogVersion)
 	event.mysqlServerVersion = make([]byte, 50)
 	pack.Read(event.mysqlServerVersion)
 
-	pack.readUint32(&event.createTimestamp)
+	return pack.readUint32(&event.createTimestamp)
 }
 
-func (eh *eventLogHeader) readHead(pack *pack) {
+func (eh *eventLogHeader) readHead(pack *pack) error {
+	//ok byte and the 19 bytes of the header
+	if pack.Len() < 20 {
+		return EVENT_TOO_SHORT_ERR
+	}
+
 	pack.ReadByte()
 	pack.readUint32(&eh.Timestamp)
 	eh.EventType, _ = pack.ReadByte()
@@ -340,12 +607,55 @@ func (eh *eventLogHeader) readHead(pack *pack) {
 	pack.readUint32(&eh.EventSize)
 	pack.readUint32(&eh.NextPosition)
 	pack.readUint16(&eh.Flags)
+	return nil
+}
+
+func (eh *eventLogHeader) Header() EventHeader {
+	return EventHeader{
+		Timestamp:    eh.Timestamp,
+		EventType:    eh.EventType,
+		ServerId:     eh.ServerId,
+		LogFileName:  eh.end.File,
+		NextPosition: eh.NextPosition,
+		EventSize:    eh.EventSize,
+		Flags:        eh.Flags,
+	}
+}
+
+func (eh *eventLogHeader) GetStartPosition() Position {
+	return eh.start
+}
+
+func (eh *eventLogHeader) GetEndPosition() Position {
+	return eh.end
+}
+
+// locate places the event in the fileName binlog, an artificial event
+// without a position stays at the position of the previous one
+func (eh *eventLogHeader) locate(fileName string, position uint32) {
+	eh.end = Position{fileName, position}
+	if eh.NextPosition != 0 {
+		eh.end.Pos = eh.NextPosition
+	}
+
+	eh.start = eh.end
+	if eh.NextPosition != 0 && eh.NextPosition >= eh.EventSize {
+		eh.start.Pos = eh.NextPosition - eh.EventSize
+	}
+}
+
+// isArtificial is true for the rotate event the master sends at the start of
+// a dump, it is not in any binlog and only names the file to follow
+func (event *logRotateEvent) isArtificial() bool {
+	return event.Flags&_LOG_EVENT_ARTIFICIAL_F != 0 || event.Timestamp == 0 || event.NextPosition == 0
 }
 
 func newEventLog(mysqlConnection *Connection, additionalLength int) *EventLog {
 	return &EventLog{
 		mysqlConnection:  mysqlConnection,
 		additionalLength: additionalLength,
+		tableMap:         map[uint64]*Table{},
+		checksumAlg:      _BINLOG_CHECKSUM_ALG_UNDEF,
 	}
 }
 
@@ -357,7 +667,97 @@ func (ev *EventLog) GetLastLogFileName() string {
 	return string(ev.lastRotateFileName)
 }
 
-func (ev *EventLog) GetEvent() (interface{}, error) {
+// SetHeartbeatEvents makes GetEvent return HeartBeatEvent, heartbeats are
+// sent by the master when it has no events during Config.HeartbeatPeriod
+func (ev *EventLog) SetHeartbeatEvents(enabled bool) {
+	ev.heartbeatEvents = enabled
+}
+
+// GetGTIDSet returns the gtid set of the dump started by StartBinlogDumpGTID
+// with every fully received transaction added. A file/position dump starts
+// the set at the PREVIOUS_GTIDS_EVENT of a binlog file, it is nil until the
+// dump reaches one.
+func (ev *EventLog) GetGTIDSet() *GTIDSet {
+	return ev.gtidSet
+}
+
+func (ev *EventLog) GetEvent() (Event, error) {
+	return ev.GetEventContext(context.Background())
+}
+
+// GetEventContext stops waiting for the next event when ctx is done,
+// the connection is not usable after that and must be closed
+func (ev *EventLog) GetEventContext(ctx context.Context) (event Event, err error) {
+	stop := ev.mysqlConnection.watchContext(ctx)
+	defer func() {
+		stop()
+		err = contextError(ctx, err)
+	}()
+
+	return ev.nextEvent(false, false)
+}
+
+// trackTransaction remembers the end of the last fully received transaction,
+// a dump restarted from there never begins in the middle of a transaction
+func (ev *EventLog) trackTransaction(event Event) {
+	switch e := event.(type) {
+	case *HeartBeatEvent:
+		return
+	case *logRotateEvent:
+		if !ev.inTransaction {
+			ev.safeFileName = string(e.binlogFileName)
+			ev.safePosition = uint32(e.position)
+		}
+		return
+	case *QueryEvent:
+		switch strings.ToUpper(e.query) {
+		case "BEGIN":
+			ev.inTransaction = true
+		case "COMMIT", "ROLLBACK":
+			ev.inTransaction = false
+		}
+	case *XidEvent:
+		ev.inTransaction = false
+	case *PreviousGtidsEvent:
+		//the executed transactions before the binlog file
+		if ev.gtidSet == nil {
+			ev.gtidSet = e.gtidSet.Clone()
+		} else {
+			ev.gtidSet.Union(e.gtidSet)
+		}
+		return
+	case *GtidEvent:
+		//the transaction of the gtid starts with the next event
+		ev.pendingGTID = nil
+		if !e.IsAnonymous() {
+			ev.pendingGTID = e
+		}
+		return
+	}
+
+	if event == nil || ev.inTransaction {
+		return
+	}
+	header := event.Header()
+
+	if ev.pendingGTID != nil {
+		if ev.gtidSet != nil {
+			ev.gtidSet.addInterval(ev.pendingGTID.sid, gtidInterval{ev.pendingGTID.gno, ev.pendingGTID.gno + 1})
+		}
+		ev.pendingGTID = nil
+	}
+
+	if header.NextPosition != 0 {
+		ev.safeFileName = header.LogFileName
+		ev.safePosition = header.NextPosition
+	}
+}
+
+// nextEvent skips XidEvent unless withXid is set, GetEvent returns it only
+// in semi-sync mode where the end of a transaction is acknowledged.
+// Rotate events are returned with withRotate only.
+func (ev *EventLog) nextEvent(withXid, withRotate bool) (Event, error) {
+	withXid = withXid || ev.semiSyncEnabled
 
 	for {
 		event, err := ev.readEvent()
@@ -366,6 +766,8 @@ func (ev *EventLog) GetEvent() (interface{}, error) {
 			return nil, err
 		}
 
+		ev.trackTransaction(event)
+
 		switch e := event.(type) {
 		case *startEventV3Event:
 			ev.binlogVersion = e.binlogVersion
@@ -383,11 +785,18 @@ func (ev *EventLog) GetEvent() (interface{}, error) {
 				ev.headerWriteRowsEventV1Length = e.eventTypeHeaderLengths[_FORMAT_DESCRIPTION_LENGTH_WRITEV1_POSITION]
 			}
 		case *logRotateEvent:
-			ev.lastRotateFileName = e.binlogFileName
+			if withRotate {
+				return e, nil
+			}
 		case *QueryEvent:
+			if !isTransactionControl(e.query) && !ev.tableFilter.MatchSchema(e.schema) {
+				continue
+			}
 			return e, nil
 		case *XidEvent:
-			continue
+			if withXid {
+				return e, nil
+			}
 		case *IntVarEvent:
 			return e, nil
 		case *BeginLoadQueryEvent:
@@ -400,9 +809,25 @@ func (ev *EventLog) GetEvent() (interface{}, error) {
 			return e, nil
 		case *RandEvent:
 			return e, nil
+		case *GtidEvent:
+			return e, nil
+		case *PreviousGtidsEvent:
+			return e, nil
 		case *TableMapEvent:
 			ev.lastTableMapEvent = e
+			if e.skipped {
+				continue
+			}
+			ev.tableMap[e.TableId] = &Table{
+				SchemaColumns: e.schemaColumns,
+				TableId:       int32(e.TableId),
+				Schema:        e.SchemaName,
+				Table:         e.TableName,
+			}
 		case *rowsEvent:
+			if e.tableMapEvent != nil && e.tableMapEvent.skipped {
+				continue
+			}
 			switch e.EventType {
 			case _DELETE_ROWS_EVENTv0:
 				fallthrough
@@ -415,6 +840,8 @@ func (ev *EventLog) GetEvent() (interface{}, error) {
 			case _UPDATE_ROWS_EVENTv1:
 				fallthrough
 			case _UPDATE_ROWS_EVENTv2:
+				fallthrough
+			case _PARTIAL_UPDATE_ROWS_EVENT:
 				return &UpdateEvent{e}, nil
 			case _WRITE_ROWS_EVENTv0:
 				fallthrough
@@ -432,7 +859,9 @@ func (ev *EventLog) GetEvent() (interface{}, error) {
 		case *ignorableEvent:
 			continue
 		case *HeartBeatEvent:
-			continue
+			if ev.heartbeatEvents {
+				return e, nil
+			}
 		case *StopEvent:
 			continue
 		case *IncidentEvent:
@@ -448,22 +877,42 @@ func (ev *EventLog) Close() {
 	ev.mysqlConnection.Close()
 }
 
-func (ev *EventLog) readEvent() (interface{}, error) {
-	pack, err := ev.mysqlConnection.packReader.readNextPackWithAdditionalLength(ev.additionalLength)
+func (ev *EventLog) readEvent() (Event, error) {
+	pack, err := ev.mysqlConnection.packReader.readNextPack()
 
 	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() && ev.watchdog > 0 {
+			return nil, HEARTBEAT_LOST_ERR
+		}
 		return nil, err
 	}
 
-	header := &eventLogHeader{}
-	header.readHead(pack)
-
 	err = pack.isError()
 
 	if err != nil {
 		return nil, err
 	}
 
+	if ev.semiSyncEnabled {
+		if err = ev.semiSync(pack); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = ev.checksum(pack); err != nil {
+		return nil, err
+	}
+
+	header := &eventLogHeader{}
+	if err = header.readHead(pack); err != nil {
+		return nil, &ParseError{
+			FileName: string(ev.lastRotateFileName),
+			Position: ev.lastRotatePosition,
+			Err:      err,
+		}
+	}
+	header.locate(string(ev.lastRotateFileName), ev.lastRotatePosition)
+
 	var event binLogEvent
 
 	switch header.EventType {
@@ -522,7 +971,7 @@ func (ev *EventLog) readEvent() (interface{}, error) {
 		}
 	case _HEARTBEAT_EVENT:
 		event = &HeartBeatEvent{
-			&unknownEvent{
+			unknownEvent: &unknownEvent{
 				eventLogHeader: header,
 			},
 		}
@@ -546,11 +995,22 @@ func (ev *EventLog) readEvent() (interface{}, error) {
 		event = &RandEvent{
 			eventLogHeader: header,
 		}
+	case _GTID_EVENT:
+		fallthrough
+	case _ANONYMOUS_GTID_EVENT:
+		event = &GtidEvent{
+			eventLogHeader: header,
+		}
+	case _PREVIOUS_GTIDS_EVENT:
+		event = &PreviousGtidsEvent{
+			eventLogHeader: header,
+		}
 	case _TABLE_MAP_EVENT:
 		event = &TableMapEvent{
 			eventLogHeader: header,
 			tableMap:       ev.tableMap,
 			ctrConn:        ev.mysqlConnection,
+			tableFilter:    ev.tableFilter,
 		}
 	case _DELETE_ROWS_EVENTv0:
 		fallthrough
@@ -564,6 +1024,8 @@ func (ev *EventLog) readEvent() (interface{}, error) {
 		fallthrough
 	case _UPDATE_ROWS_EVENTv2:
 		fallthrough
+	case _PARTIAL_UPDATE_ROWS_EVENT:
+		fallthrough
 	case _WRITE_ROWS_EVENTv0:
 		fallthrough
 	case _WRITE_ROWS_EVENTv1:
@@ -573,6 +1035,7 @@ func (ev *EventLog) readEvent() (interface{}, error) {
 			eventLogHeader:   header,
 			postHeaderLength: ev.headerWriteRowsEventV1Length,
 			tableMapEvent:    ev.lastTableMapEvent,
+			location:         ev.mysqlConnection.config.location(),
 		}
 	default:
 		//		println("Unknown event")
@@ -580,8 +1043,30 @@ func (ev *EventLog) readEvent() (interface{}, error) {
 		return nil, nil
 	}
 
-	ev.lastRotatePosition = header.NextPosition
+	if err = event.read(pack); err != nil {
+		return nil, &ParseError{
+			FileName:  header.start.File,
+			Position:  header.start.Pos,
+			EventType: header.EventType,
+			Err:       err,
+		}
+	}
+
+	switch e := event.(type) {
+	case *logRotateEvent:
+		next := Position{string(e.binlogFileName), uint32(e.position)}
+		if e.isArtificial() {
+			header.start, header.end = next, next
+		}
+		ev.lastRotateFileName = e.binlogFileName
+		ev.lastRotatePosition = next.Pos
+	case *HeartBeatEvent:
+		//the master position, heartbeats are not written to the binlog
+		header.start = Position{e.logFileName, header.NextPosition}
+		header.end = header.start
+	default:
+		ev.lastRotatePosition = header.end.Pos
+	}
 
-	event.read(pack)
 	return event, nil
 }
@@ -2,6 +2,9 @@ package myreplication
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"strings"
@@ -12,6 +15,7 @@ import (
 type (
 	rowEventTestCase struct {
 		tableMapEventBuff []byte
+		unsignedColumns   []int
 		rowsEventBuff     []byte
 		expectedValues    [][]*RowsEventValue
 		expectedNewValues [][]*RowsEventValue
@@ -652,6 +656,166 @@ func TestRandEvent(t *testing.T) {
 	}
 }
 
+func TestGtidEvent(t *testing.T) {
+	type gtidEventTestCase struct {
+		buff                     []byte
+		gtid                     string
+		commitFlag               byte
+		lastCommitted            int64
+		sequenceNumber           int64
+		immediateCommitTimestamp uint64
+		originalCommitTimestamp  uint64
+		transactionLength        uint64
+	}
+
+	testCases := []*gtidEventTestCase{
+		//MySQL 5.6
+		&gtidEventTestCase{
+			[]byte{
+				0x2d, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x10, 0x5e, 0x5f, 0x21, 0x01, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62, 0x2a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:42", 1, 0, 0, 0, 0, 0,
+		},
+		//MySQL 5.7
+		&gtidEventTestCase{
+			[]byte{
+				0x3e, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x10, 0x5e, 0x5f, 0x21, 0x01, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62, 0x2a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:42", 1, 7, 9, 0, 0, 0,
+		},
+		//MySQL 8.0, original commit timestamp and server versions differ
+		&gtidEventTestCase{
+			[]byte{
+				0x57, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x10, 0x5e, 0x5f, 0x21, 0x01, 0x00, 0x00, 0x00, 0x56, 0x00, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62, 0x2b, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x40, 0xe2, 0xa5, 0x07, 0x31, 0xaf, 0x85, 0x01, 0x00, 0xa4, 0x07, 0x31, 0xaf, 0x05,
+				0xfc, 0x2c, 0x01,
+				0x97, 0x38, 0x01, 0x80, 0x93, 0x38, 0x01, 0x00,
+			},
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:43", 0, 8, 10, 1600000000123456, 1600000000000001, 300,
+		},
+		//MySQL 8.0 anonymous
+		&gtidEventTestCase{
+			[]byte{
+				0x46, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x10, 0x5e, 0x5f, 0x22, 0x01, 0x00, 0x00, 0x00, 0x45, 0x00, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x40, 0xe2, 0xa5, 0x07, 0x31, 0xaf, 0x05,
+				0x7b,
+			},
+			"", 1, 3, 4, 1600000000123456, 1600000000123456, 123,
+		},
+	}
+
+	for i, testCase := range testCases {
+		packReader := newPackReader(bytes.NewBuffer(testCase.buff))
+		pack, _ := packReader.readNextPack()
+
+		header := &eventLogHeader{}
+		header.readHead(pack)
+
+		gtid := &GtidEvent{}
+		gtid.eventLogHeader = header
+		gtid.read(pack)
+
+		if gtid.GetGTID() != testCase.gtid {
+			t.Fatal(
+				"Incorrect gtid at test", i,
+				"expected", testCase.gtid,
+				"got", gtid.GetGTID(),
+			)
+		}
+
+		if gtid.IsAnonymous() != (testCase.gtid == "") {
+			t.Fatal("Incorrect anonymous flag at test", i)
+		}
+
+		if gtid.GetCommitFlag() != testCase.commitFlag {
+			t.Fatal(
+				"Incorrect commit flag at test", i,
+				"expected", testCase.commitFlag,
+				"got", gtid.GetCommitFlag(),
+			)
+		}
+
+		if gtid.GetLastCommitted() != testCase.lastCommitted || gtid.GetSequenceNumber() != testCase.sequenceNumber {
+			t.Fatal(
+				"Incorrect logical timestamps at test", i,
+				"expected", testCase.lastCommitted, testCase.sequenceNumber,
+				"got", gtid.GetLastCommitted(), gtid.GetSequenceNumber(),
+			)
+		}
+
+		if gtid.GetImmediateCommitTimestamp() != testCase.immediateCommitTimestamp ||
+			gtid.GetOriginalCommitTimestamp() != testCase.originalCommitTimestamp {
+			t.Fatal(
+				"Incorrect commit timestamps at test", i,
+				"expected", testCase.immediateCommitTimestamp, testCase.originalCommitTimestamp,
+				"got", gtid.GetImmediateCommitTimestamp(), gtid.GetOriginalCommitTimestamp(),
+			)
+		}
+
+		if gtid.GetTransactionLength() != testCase.transactionLength {
+			t.Fatal(
+				"Incorrect transaction length at test", i,
+				"expected", testCase.transactionLength,
+				"got", gtid.GetTransactionLength(),
+			)
+		}
+	}
+}
+
+func TestPreviousGtidsEvent(t *testing.T) {
+	mockHandshake := []byte{
+		//pack header
+		0x54, 0x00, 0x00,
+		0x01,
+		//event header
+		0x00, 0x00, 0x10, 0x5e, 0x5f, 0x23, 0x01, 0x00, 0x00, 0x00, 0x53, 0x00, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	packReader := newPackReader(bytes.NewBuffer(mockHandshake))
+	pack, _ := packReader.readNextPack()
+
+	header := &eventLogHeader{}
+	header.readHead(pack)
+
+	previous := &PreviousGtidsEvent{}
+	previous.eventLogHeader = header
+	previous.read(pack)
+
+	if previous.EventType != _PREVIOUS_GTIDS_EVENT {
+		t.Fatal(
+			"Incorrect event type",
+			"expected", _PREVIOUS_GTIDS_EVENT,
+			"got", previous.EventType,
+		)
+	}
+
+	expectedSet := "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:7"
+
+	if previous.GetGTIDSet().String() != expectedSet {
+		t.Fatal(
+			"Incorrect gtid set",
+			"expected", expectedSet,
+			"got", previous.GetGTIDSet().String(),
+		)
+	}
+}
+
 func getTableMapEvent(mockHandshake []byte) *TableMapEvent {
 	packReader := newPackReader(bytes.NewBuffer(mockHandshake))
 	pack, _ := packReader.readNextPack()
@@ -666,50 +830,60 @@ func getTableMapEvent(mockHandshake []byte) *TableMapEvent {
 	return table
 }
 
-func TestTableMapEvent(t *testing.T) {
+// a TABLE_MAP_EVENT of the table test.types with 19 numeric columns
+var typesTableMapEvent = []byte{
+	//pack header
+	0x49, 0x00, 0x00,
+	0x01,
+	//event header
+	0x00,
+	0x5d, 0xff, 0x86, 0x54,
+	0x13,
+	0x01, 0x00, 0x00, 0x00,
+	0x48, 0x00, 0x00, 0x00,
+	0x34, 0x06, 0x00, 0x00,
+	0x00, 0x00,
+	//body
+	//table id
+	0x2c, 0x00, 0x00, 0x00, 0x00, 0x00,
+	//flags
+	0x01, 0x00,
+	//schema length
+	0x04,
+	//schema name "test"
+	0x74, 0x65, 0x73, 0x74,
+	//filler
+	0x00,
+	//table name length
+	0x05,
+	//table name "types"
+	0x74, 0x79, 0x70, 0x65, 0x73,
+	//filler
+	0x00,
+	//column count
+	0x13,
+	//column count def
+	0x03, 0x01, 0x01, 0x02, 0x02, 0x09, 0x09, 0x03, 0x03, 0x03, 0x03, 0x08, 0x08, 0xf6, 0xf6, 0x05, 0x05, 0x04, 0x04,
+	//meta info length
+	0x08,
+	//meta info
+	0x0a, 0x00, 0x0a, 0x00, 0x08, 0x08, 0x04, 0x04,
+	//bit mask
+	0x6e, 0xfb, 0x07,
+}
 
-	mockHandshake := []byte{
-		//pack header
-		0x49, 0x00, 0x00,
-		0x01,
-		//event header
-		0x00,
-		0x5d, 0xff, 0x86, 0x54,
-		0x13,
-		0x01, 0x00, 0x00, 0x00,
-		0x48, 0x00, 0x00, 0x00,
-		0x34, 0x06, 0x00, 0x00,
-		0x00, 0x00,
-		//body
-		//table id
-		0x2c, 0x00, 0x00, 0x00, 0x00, 0x00,
-		//flags
-		0x01, 0x00,
-		//schema length
-		0x04,
-		//schema name "test"
-		0x74, 0x65, 0x73, 0x74,
-		//filler
-		0x00,
-		//table name length
-		0x05,
-		//table name "types"
-		0x74, 0x79, 0x70, 0x65, 0x73,
-		//filler
-		0x00,
-		//column count
-		0x13,
-		//column count def
-		0x03, 0x01, 0x01, 0x02, 0x02, 0x09, 0x09, 0x03, 0x03, 0x03, 0x03, 0x08, 0x08, 0xf6, 0xf6, 0x05, 0x05, 0x04, 0x04,
-		//meta info length
-		0x08,
-		//meta info
-		0x0a, 0x00, 0x0a, 0x00, 0x08, 0x08, 0x04, 0x04,
-		//bit mask
-		0x6e, 0xfb, 0x07,
+// getTableMapEvent reads the table map of the test case, without a control
+// connection the unsigned columns of the schema are set by hand
+func (testCase *rowEventTestCase) getTableMapEvent() *TableMapEvent {
+	table := getTableMapEvent(testCase.tableMapEventBuff)
+	for _, i := range testCase.unsignedColumns {
+		table.Columns[i].Unsigned = true
 	}
+	return table
+}
 
-	table := getTableMapEvent(mockHandshake)
+func TestTableMapEvent(t *testing.T) {
+	table := getTableMapEvent(typesTableMapEvent)
 
 	if table.EventType != _TABLE_MAP_EVENT {
 		t.Fatal(
@@ -787,15 +961,13 @@ func TestTableMapEvent(t *testing.T) {
 			)
 		}
 
-		/*
-			if testColumn.Nullable != testCase.expectedIsNull {
-				t.Fatal(
-					"Incorrect null flag with index", i,
-					"expected", testCase.expectedIsNull,
-					"got", testColumn.Nullable,
-				)
-			}
-		*/
+		if testColumn.Nullable != testCase.expectedIsNull {
+			t.Fatal(
+				"Incorrect null flag with index", i,
+				"expected", testCase.expectedIsNull,
+				"got", testColumn.Nullable,
+			)
+		}
 		/*
 			if !reflect.DeepEqual(testColumn.MetaInfo, testCase.expectedMetaInfo) {
 				t.Fatal(
@@ -807,14 +979,14 @@ func TestTableMapEvent(t *testing.T) {
 	}
 }
 
-func TestWriteRowsEventV1(t *testing.T) {
+func writeRowsEventTestCases() []*rowEventTestCase {
 
 	rat12, _ := new(big.Rat).SetString("12")
 	rat13, _ := new(big.Rat).SetString("13")
 	rat29, _ := new(big.Rat).SetString("29")
 	rat30, _ := new(big.Rat).SetString("30")
 
-	testCases := []*rowEventTestCase{
+	return []*rowEventTestCase{
 		&rowEventTestCase{
 			tableMapEventBuff: []byte{
 				0x40, 0x00, 0x00, 0x05, // packet head
@@ -847,11 +1019,11 @@ func TestWriteRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint64(0), MYSQL_TYPE_LONGLONG},
-					&RowsEventValue{1, false, uint32(230600), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int64(0), MYSQL_TYPE_LONGLONG},
+					&RowsEventValue{1, false, int32(230600), MYSQL_TYPE_LONG},
 					&RowsEventValue{2, false, `{"city_name": "\u5317\u4eac", "latitude": 3992427, "longitude": 11645567}`, MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{3, false, time.Date(2014, time.Month(11), 13, 14, 00, 57, 00, time.Local), MYSQL_TYPE_DATETIME},
-					&RowsEventValue{4, false, time.Date(2015, time.Month(3), 30, 06, 50, 44, 00, time.Local), MYSQL_TYPE_TIMESTAMP},
+					&RowsEventValue{4, false, time.Date(2015, time.Month(3), 29, 22, 50, 44, 00, time.UTC).In(time.Local), MYSQL_TYPE_TIMESTAMP},
 				},
 			},
 		},
@@ -881,7 +1053,7 @@ func TestWriteRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(12), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(12), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
@@ -904,7 +1076,7 @@ func TestWriteRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(13), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(13), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, true, nil, MYSQL_TYPE_DOUBLE},
 				},
@@ -943,12 +1115,12 @@ func TestWriteRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(14), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(14), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, true, nil, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(15), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(15), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hello", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 22.0, MYSQL_TYPE_DOUBLE},
 				},
@@ -986,6 +1158,7 @@ func TestWriteRowsEventV1(t *testing.T) {
 				0x04,
 				0x6e, 0xfb, 0x07,
 			},
+			unsignedColumns: []int{2, 4, 6, 8, 10, 12},
 			rowsEventBuff: []byte{
 				0xB0, 0x00, 0x00,
 				0x01,
@@ -1044,16 +1217,16 @@ func TestWriteRowsEventV1(t *testing.T) {
 
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(2), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(2), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, true, nil, MYSQL_TYPE_TINY},
-					&RowsEventValue{2, false, byte(1), MYSQL_TYPE_TINY},
-					&RowsEventValue{3, false, uint16(2), MYSQL_TYPE_SHORT},
+					&RowsEventValue{2, false, uint8(1), MYSQL_TYPE_TINY},
+					&RowsEventValue{3, false, int16(2), MYSQL_TYPE_SHORT},
 					&RowsEventValue{4, false, uint16(3), MYSQL_TYPE_SHORT},
-					&RowsEventValue{5, false, uint32(4), MYSQL_TYPE_INT24},
+					&RowsEventValue{5, false, int32(4), MYSQL_TYPE_INT24},
 					&RowsEventValue{6, false, uint32(5), MYSQL_TYPE_INT24},
-					&RowsEventValue{7, false, uint32(6), MYSQL_TYPE_LONG},
+					&RowsEventValue{7, false, int32(6), MYSQL_TYPE_LONG},
 					&RowsEventValue{8, false, uint32(7), MYSQL_TYPE_LONG},
-					&RowsEventValue{9, false, uint32(8), MYSQL_TYPE_LONG},
+					&RowsEventValue{9, false, int32(8), MYSQL_TYPE_LONG},
 					&RowsEventValue{10, false, uint32(9), MYSQL_TYPE_LONG},
 					&RowsEventValue{11, true, nil, MYSQL_TYPE_LONGLONG},
 					&RowsEventValue{12, false, uint64(11), MYSQL_TYPE_LONGLONG},
@@ -1065,18 +1238,18 @@ func TestWriteRowsEventV1(t *testing.T) {
 					&RowsEventValue{18, false, float32(17), MYSQL_TYPE_FLOAT},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(3), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(3), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, true, nil, MYSQL_TYPE_TINY},
-					&RowsEventValue{2, false, byte(18), MYSQL_TYPE_TINY},
-					&RowsEventValue{3, false, uint16(19), MYSQL_TYPE_SHORT},
+					&RowsEventValue{2, false, uint8(18), MYSQL_TYPE_TINY},
+					&RowsEventValue{3, false, int16(19), MYSQL_TYPE_SHORT},
 					&RowsEventValue{4, false, uint16(20), MYSQL_TYPE_SHORT},
-					&RowsEventValue{5, false, uint32(21), MYSQL_TYPE_INT24},
+					&RowsEventValue{5, false, int32(21), MYSQL_TYPE_INT24},
 					&RowsEventValue{6, false, uint32(22), MYSQL_TYPE_INT24},
-					&RowsEventValue{7, false, uint32(23), MYSQL_TYPE_LONG},
+					&RowsEventValue{7, false, int32(23), MYSQL_TYPE_LONG},
 					&RowsEventValue{8, false, uint32(24), MYSQL_TYPE_LONG},
-					&RowsEventValue{9, false, uint32(25), MYSQL_TYPE_LONG},
+					&RowsEventValue{9, false, int32(25), MYSQL_TYPE_LONG},
 					&RowsEventValue{10, false, uint32(26), MYSQL_TYPE_LONG},
-					&RowsEventValue{11, false, uint64(27), MYSQL_TYPE_LONGLONG},
+					&RowsEventValue{11, false, int64(27), MYSQL_TYPE_LONGLONG},
 					&RowsEventValue{12, false, uint64(28), MYSQL_TYPE_LONGLONG},
 					&RowsEventValue{13, false, rat29, MYSQL_TYPE_NEWDECIMAL},
 					&RowsEventValue{14, false, rat30, MYSQL_TYPE_NEWDECIMAL},
@@ -1088,6 +1261,10 @@ func TestWriteRowsEventV1(t *testing.T) {
 			},
 		},
 	}
+}
+
+func TestWriteRowsEventV1(t *testing.T) {
+	testCases := writeRowsEventTestCases()
 
 	for i, testCase := range testCases {
 		packReader := newPackReader(bytes.NewBuffer(testCase.rowsEventBuff))
@@ -1095,13 +1272,31 @@ func TestWriteRowsEventV1(t *testing.T) {
 
 		header := &eventLogHeader{}
 		header.readHead(pack)
+		headerFlags := header.Flags
 
 		write := &rowsEvent{}
 		write.eventLogHeader = header
 		write.postHeaderLength = byte(8)
-		write.tableMapEvent = getTableMapEvent(testCase.tableMapEventBuff)
+		write.tableMapEvent = testCase.getTableMapEvent()
 		write.read(pack)
 
+		if write.Header().Flags != headerFlags {
+			t.Fatal(
+				"Incorrect header flags at test", i,
+				"expected", headerFlags,
+				"got", write.Header().Flags,
+			)
+		}
+
+		//the rows of a single event end the statement
+		if write.GetRowsFlags()&0x0001 == 0 {
+			t.Fatal(
+				"Incorrect rows flags at test", i,
+				"expected", "end of statement",
+				"got", write.GetRowsFlags(),
+			)
+		}
+
 		if write.EventType != _WRITE_ROWS_EVENTv1 {
 			t.Fatal(
 				"Incorrect event type",
@@ -1159,9 +1354,9 @@ func TestWriteRowsEventV1(t *testing.T) {
 	}
 }
 
-func TestDeleteRowsEventV1(t *testing.T) {
+func deleteRowsEventTestCases() []*rowEventTestCase {
 	rat333 := new(big.Rat).SetInt64(333)
-	testCases := []*rowEventTestCase{
+	return []*rowEventTestCase{
 		&rowEventTestCase{
 			tableMapEventBuff: []byte{
 				0x2E, 0x00, 0x00, 0x01,
@@ -1177,7 +1372,7 @@ func TestDeleteRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(6), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(6), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, rat333, MYSQL_TYPE_NEWDECIMAL},
 				},
 			},
@@ -1199,23 +1394,27 @@ func TestDeleteRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(13), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(13), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, true, nil, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(14), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(14), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, true, nil, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(15), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(15), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hello", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 22.0, MYSQL_TYPE_DOUBLE},
 				},
 			},
 		},
 	}
+}
+
+func TestDeleteRowsEventV1(t *testing.T) {
+	testCases := deleteRowsEventTestCases()
 
 	for i, testCase := range testCases {
 		packReader := newPackReader(bytes.NewBuffer(testCase.rowsEventBuff))
@@ -1227,7 +1426,7 @@ func TestDeleteRowsEventV1(t *testing.T) {
 		delete := &rowsEvent{}
 		delete.eventLogHeader = header
 		delete.postHeaderLength = byte(8)
-		delete.tableMapEvent = getTableMapEvent(testCase.tableMapEventBuff)
+		delete.tableMapEvent = testCase.getTableMapEvent()
 		delete.read(pack)
 
 		if delete.EventType != _DELETE_ROWS_EVENTv1 {
@@ -1287,8 +1486,8 @@ func TestDeleteRowsEventV1(t *testing.T) {
 	}
 }
 
-func TestUpdateRowsEventV1(t *testing.T) {
-	testCases := []*rowEventTestCase{
+func updateRowsEventTestCases() []*rowEventTestCase {
+	return []*rowEventTestCase{
 		&rowEventTestCase{
 			tableMapEventBuff: []byte{
 				0x34, 0x00, 0x00, 0x01,
@@ -1367,40 +1566,44 @@ func TestUpdateRowsEventV1(t *testing.T) {
 			},
 			expectedValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(10), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(10), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(11), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(11), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(12), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(12), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hi", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
 			},
 			expectedNewValues: [][]*RowsEventValue{
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(10), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(10), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hello", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(11), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(11), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hello", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
 				[]*RowsEventValue{
-					&RowsEventValue{0, false, uint32(12), MYSQL_TYPE_LONG},
+					&RowsEventValue{0, false, int32(12), MYSQL_TYPE_LONG},
 					&RowsEventValue{1, false, "hello", MYSQL_TYPE_VARCHAR},
 					&RowsEventValue{2, false, 3.14, MYSQL_TYPE_DOUBLE},
 				},
 			},
 		},
 	}
+}
+
+func TestUpdateRowsEventV1(t *testing.T) {
+	testCases := updateRowsEventTestCases()
 
 	for i, testCase := range testCases {
 		packReader := newPackReader(bytes.NewBuffer(testCase.rowsEventBuff))
@@ -1412,7 +1615,7 @@ func TestUpdateRowsEventV1(t *testing.T) {
 		update := &rowsEvent{}
 		update.eventLogHeader = header
 		update.postHeaderLength = byte(8)
-		update.tableMapEvent = getTableMapEvent(testCase.tableMapEventBuff)
+		update.tableMapEvent = testCase.getTableMapEvent()
 		update.read(pack)
 
 		if update.EventType != _UPDATE_ROWS_EVENTv1 {
@@ -1518,3 +1721,602 @@ func TestUpdateRowsEventV1(t *testing.T) {
 		}
 	}
 }
+
+func TestEventHeader(t *testing.T) {
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 0)
+
+	go writeStandInEvents(server,
+		standInEvent(_ROTATE_EVENT, 0, standInRotateBody("mysql-bin.000007", 4)),
+		standInEvent(_QUERY_EVENT, 320, standInQueryBody("test", "CREATE TABLE t (id int)")),
+	)
+
+	var event Event
+	for {
+		var err error
+		event, err = el.GetEvent()
+		if err != nil {
+			t.Fatal("Get event fail", err)
+		}
+		if _, ok := event.(*QueryEvent); ok {
+			break
+		}
+	}
+
+	header := event.Header()
+	if header.EventType != _QUERY_EVENT || header.LogFileName != "mysql-bin.000007" ||
+		header.NextPosition != 320 || header.ServerId != 1 || header.Timestamp == 0 {
+		t.Fatal(
+			"Incorrect event header",
+			"expected", _QUERY_EVENT, "mysql-bin.000007", 320, 1,
+			"got", header.EventType, header.LogFileName, header.NextPosition, header.ServerId,
+		)
+	}
+
+	if int(header.EventSize) != 19+len(standInQueryBody("test", "CREATE TABLE t (id int)")) {
+		t.Fatal("Incorrect event size", "got", header.EventSize)
+	}
+}
+
+func TestEventPosition(t *testing.T) {
+	artificialRotate := standInEvent(_ROTATE_EVENT, 0, standInRotateBody("mysql-bin.000007", 4))
+	copy(artificialRotate[0:4], []byte{0x00, 0x00, 0x00, 0x00})
+	writeUInt16(artificialRotate[17:19], _LOG_EVENT_ARTIFICIAL_F)
+
+	rotate := standInEvent(_ROTATE_EVENT, 400, standInRotateBody("mysql-bin.000008", 4))
+
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 0)
+
+	go writeStandInPackets(server,
+		artificialRotate,
+		standInEvent(_FORMAT_DESCRIPTION_EVENT, 0, standInFormatDescriptionBody()),
+		standInEvent(_INTVAR_EVENT, 320, standInIntVarBody(1)),
+		rotate,
+		standInEvent(_INTVAR_EVENT, 200, standInIntVarBody(2)),
+	)
+
+	intVarSize := uint32(19 + len(standInIntVarBody(1)))
+
+	type positionTestCase struct {
+		start Position
+		end   Position
+	}
+
+	testCases := []*positionTestCase{
+		&positionTestCase{Position{"mysql-bin.000007", 4}, Position{"mysql-bin.000007", 4}},
+		&positionTestCase{Position{"mysql-bin.000007", 4}, Position{"mysql-bin.000007", 4}},
+		&positionTestCase{Position{"mysql-bin.000007", 320 - intVarSize}, Position{"mysql-bin.000007", 320}},
+		&positionTestCase{Position{"mysql-bin.000007", 400 - uint32(len(rotate))}, Position{"mysql-bin.000007", 400}},
+		&positionTestCase{Position{"mysql-bin.000008", 200 - intVarSize}, Position{"mysql-bin.000008", 200}},
+	}
+
+	for i, testCase := range testCases {
+		event, err := el.readEvent()
+		if err != nil {
+			t.Fatal("Read event fail at test", i, err)
+		}
+
+		if event.GetStartPosition() != testCase.start || event.GetEndPosition() != testCase.end {
+			t.Fatal(
+				"Incorrect event position at test", i,
+				"expected", testCase.start, testCase.end,
+				"got", event.GetStartPosition(), event.GetEndPosition(),
+			)
+		}
+	}
+
+	if el.GetLastLogFileName() != "mysql-bin.000008" || el.GetLastPosition() != 200 {
+		t.Fatal(
+			"Incorrect last position",
+			"expected", "mysql-bin.000008", 200,
+			"got", el.GetLastLogFileName(), el.GetLastPosition(),
+		)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	//the schema of the query is not terminated
+	brokenQuery := standInQueryBody("test", "")
+	brokenQuery = brokenQuery[:len(brokenQuery)-1]
+
+	testCases := [][]byte{
+		standInEvent(_QUERY_EVENT, 500, brokenQuery),
+		standInEvent(_XID_EVENT, 500, []byte{0x01, 0x02}),
+		standInEvent(_TABLE_MAP_EVENT, 500, standInTableMapBody(1, "test", "t")[:10]),
+		standInEvent(_WRITE_ROWS_EVENTv1, 500, standInWriteRowsBody(1, 1)),
+	}
+
+	for i, testCase := range testCases {
+		connection, server := newPipeConnection()
+		el := newEventLog(connection, 0)
+
+		go writeStandInEvents(server,
+			standInEvent(_ROTATE_EVENT, 0, standInRotateBody("mysql-bin.000003", 4)),
+			testCase,
+		)
+
+		_, err := el.GetEvent()
+		server.Close()
+
+		parseErr, ok := err.(*ParseError)
+		if !ok {
+			t.Fatal("Incorrect error at test", i, "expected", "*ParseError", "got", err)
+		}
+
+		expectedPosition := 500 - uint32(len(testCase))
+		if parseErr.EventType != testCase[4] || parseErr.FileName != "mysql-bin.000003" || parseErr.Position != expectedPosition {
+			t.Fatal(
+				"Incorrect parse error at test", i,
+				"expected", testCase[4], "mysql-bin.000003", expectedPosition,
+				"got", parseErr.EventType, parseErr.FileName, parseErr.Position,
+			)
+		}
+	}
+}
+
+func TestSignedRowsEvent(t *testing.T) {
+	table := &TableMapEvent{
+		Columns: []*Column{
+			&Column{Type: MYSQL_TYPE_TINY},
+			&Column{Type: MYSQL_TYPE_TINY, Unsigned: true},
+			&Column{Type: MYSQL_TYPE_SHORT},
+			&Column{Type: MYSQL_TYPE_INT24},
+			&Column{Type: MYSQL_TYPE_INT24, Unsigned: true},
+			&Column{Type: MYSQL_TYPE_LONG},
+			&Column{Type: MYSQL_TYPE_LONGLONG},
+		},
+	}
+
+	body := []byte{
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+		0x00, 0x00, // flags
+		0x07, 0x7f, // column count, present bitmap
+		//row of -1 and the maximum unsigned values
+		0x00,
+		0xff,
+		0xff,
+		0xfe, 0xff,
+		0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0x80,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		//row of the minimum signed values
+		0x00,
+		0x80,
+		0x7f,
+		0x00, 0x80,
+		0x00, 0x00, 0x80,
+		0x00, 0x00, 0x80,
+		0xff, 0xff, 0xff, 0x7f,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80,
+	}
+
+	expected := [][]interface{}{
+		[]interface{}{int8(-1), uint8(255), int16(-2), int32(-1), uint32(16777215), int32(math.MinInt32), int64(-1)},
+		[]interface{}{int8(-128), uint8(127), int16(-32768), int32(-8388608), uint32(8388608), int32(math.MaxInt32), int64(math.MinInt64)},
+	}
+
+	rows := &rowsEvent{
+		eventLogHeader:   &eventLogHeader{EventType: _WRITE_ROWS_EVENTv1},
+		tableMapEvent:    table,
+		postHeaderLength: 8,
+	}
+
+	if err := rows.read(newPackWithBuff(body)); err != nil {
+		t.Fatal("Read rows event fail", err)
+	}
+
+	if len(rows.values) != len(expected) {
+		t.Fatal("Incorrect rows count", "expected", len(expected), "got", len(rows.values))
+	}
+
+	for i, row := range rows.values {
+		for j, value := range row {
+			if value.GetValue() != expected[i][j] {
+				t.Fatal(
+					"Incorrect value at row", i, "column", j,
+					"expected", expected[i][j],
+					"got", value.GetValue(),
+				)
+			}
+		}
+	}
+}
+
+func TestTemporal2RowsEvent(t *testing.T) {
+	table := &TableMapEvent{
+		Columns: []*Column{
+			&Column{Type: MYSQL_TYPE_TIMESTAMP2, Fsp: 3},
+			&Column{Type: MYSQL_TYPE_TIME2, Fsp: 2},
+			&Column{Type: MYSQL_TYPE_DATETIME2, Fsp: 0},
+			&Column{Type: MYSQL_TYPE_LONG},
+		},
+	}
+
+	body := []byte{
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+		0x00, 0x00, // flags
+		0x04, 0x0f, // column count, present bitmap
+		0x00,
+		0x55, 0x18, 0x81, 0xc4, 0x04, 0xce,
+		0x7f, 0xff, 0xfe, 0xce,
+		0x99, 0x95, 0xbb, 0x6c, 0xac,
+		0x07, 0x00, 0x00, 0x00,
+	}
+
+	loc := time.UTC
+	rows := &rowsEvent{
+		eventLogHeader:   &eventLogHeader{EventType: _WRITE_ROWS_EVENTv1},
+		tableMapEvent:    table,
+		postHeaderLength: 8,
+		location:         loc,
+	}
+
+	if err := rows.read(newPackWithBuff(body)); err != nil {
+		t.Fatal("Read rows event fail", err)
+	}
+
+	expected := []interface{}{
+		time.Unix(1427669444, 123000000).In(loc),
+		-(time.Second + 500*time.Millisecond),
+		time.Date(2015, 3, 29, 22, 50, 44, 0, loc),
+		int32(7),
+	}
+
+	if len(rows.values) != 1 || len(rows.values[0]) != len(expected) {
+		t.Fatal("Incorrect rows", "expected", expected, "got", rows.values)
+	}
+
+	for i, value := range rows.values[0] {
+		if !reflect.DeepEqual(value.GetValue(), expected[i]) {
+			t.Fatal(
+				"Incorrect value at column", i,
+				"expected", expected[i],
+				"got", value.GetValue(),
+			)
+		}
+	}
+}
+
+func TestEnumSetRowsEvent(t *testing.T) {
+	table := &TableMapEvent{
+		Columns: []*Column{
+			&Column{Type: MYSQL_TYPE_ENUM, Size: 1, EnumValues: []string{"a", "b", "c"}},
+			&Column{Type: MYSQL_TYPE_ENUM, Size: 2},
+			&Column{Type: MYSQL_TYPE_ENUM, Size: 1, EnumValues: []string{"a", "b", "c"}},
+			&Column{Type: MYSQL_TYPE_SET, Size: 1, SetValues: []string{"x", "y", "z"}},
+			&Column{Type: MYSQL_TYPE_SET, Size: 8},
+			&Column{Type: MYSQL_TYPE_SET, Size: 1, SetValues: []string{"x", "y", "z"}},
+			&Column{Type: MYSQL_TYPE_LONG},
+		},
+	}
+
+	body := []byte{
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+		0x00, 0x00, // flags
+		0x07, 0x7f, // column count, present bitmap
+		0x00,
+		0x02,
+		0x2c, 0x01,
+		0x00,
+		0x05,
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80,
+		0x00,
+		0x09, 0x00, 0x00, 0x00,
+	}
+
+	rows := &rowsEvent{
+		eventLogHeader:   &eventLogHeader{EventType: _WRITE_ROWS_EVENTv1},
+		tableMapEvent:    table,
+		postHeaderLength: 8,
+	}
+
+	if err := rows.read(newPackWithBuff(body)); err != nil {
+		t.Fatal("Read rows event fail", err)
+	}
+
+	expected := []interface{}{
+		&EnumValue{Index: 2, Label: "b"},
+		&EnumValue{Index: 300},
+		&EnumValue{Index: 0},
+		&SetValue{Bits: 0x05, Labels: []string{"x", "z"}},
+		&SetValue{Bits: 0x8000000000000201},
+		&SetValue{Bits: 0, Labels: []string{}},
+		int32(9),
+	}
+
+	if len(rows.values) != 1 || len(rows.values[0]) != len(expected) {
+		t.Fatal("Incorrect rows", "expected", expected, "got", rows.values)
+	}
+
+	for i, value := range rows.values[0] {
+		if !reflect.DeepEqual(value.GetValue(), expected[i]) {
+			t.Fatal(
+				"Incorrect value at column", i,
+				"expected", expected[i],
+				"got", value.GetValue(),
+			)
+		}
+	}
+}
+
+func TestBitRowsEvent(t *testing.T) {
+	for length := 1; length <= 64; length++ {
+		column, err := newColumn(newPackWithBuff([]byte{byte(length % 8), byte(length / 8)}), MYSQL_TYPE_BIT)
+		if err != nil {
+			t.Fatal("Read BIT metadata fail at length", length, err)
+		}
+
+		expectedBytes := (length + 7) / 8
+		if int(column.Bits) != length || column.Bytes != expectedBytes {
+			t.Fatal(
+				"Incorrect BIT metadata at length", length,
+				"expected", length, expectedBytes,
+				"got", column.Bits, column.Bytes,
+			)
+		}
+
+		expected := uint64(0xa5a5a5a5a5a5a5a5) >> uint(64-length)
+
+		body := []byte{
+			0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+			0x00, 0x00, // flags
+			0x01, 0x01, // column count, present bitmap
+			0x00,
+		}
+		for i := expectedBytes - 1; i >= 0; i-- {
+			body = append(body, byte(expected>>uint(i*8)))
+		}
+
+		rows := &rowsEvent{
+			eventLogHeader:   &eventLogHeader{EventType: _WRITE_ROWS_EVENTv1},
+			tableMapEvent:    &TableMapEvent{Columns: []*Column{column}},
+			postHeaderLength: 8,
+		}
+
+		if err := rows.read(newPackWithBuff(body)); err != nil {
+			t.Fatal("Read rows event fail at length", length, err)
+		}
+
+		value := rows.values[0][0].GetValue()
+		if value != expected {
+			t.Fatal("Incorrect BIT value at length", length, "expected", expected, "got", value)
+		}
+
+		expectedString := fmt.Sprintf("%0*b", length, expected)
+		if column.BitString(expected) != expectedString {
+			t.Fatal("Incorrect bit string at length", length, "expected", expectedString, "got", column.BitString(expected))
+		}
+
+		bools := column.BitBools(expected)
+		if len(bools) != length {
+			t.Fatal("Incorrect bits count at length", length, "expected", length, "got", len(bools))
+		}
+		for i, bit := range bools {
+			if bit != (expectedString[length-1-i] == '1') {
+				t.Fatal("Incorrect bit", i, "at length", length, "expected", !bit, "got", bit)
+			}
+		}
+	}
+
+	//BIT(65), bits above 7 and a length that overflows a byte
+	for _, metadata := range [][]byte{{0x01, 0x08}, {0x08, 0x00}, {0xc8, 0x07}} {
+		if _, err := newColumn(newPackWithBuff(metadata), MYSQL_TYPE_BIT); err == nil {
+			t.Fatal("Incorrect BIT metadata accepted", metadata)
+		}
+	}
+}
+
+func TestJSONRowsEvent(t *testing.T) {
+	column, err := newColumn(newPackWithBuff([]byte{0x04}), MYSQL_TYPE_JSON)
+	if err != nil {
+		t.Fatal("Read JSON metadata fail", err)
+	}
+
+	if column.LenSize != 4 {
+		t.Fatal("Incorrect JSON length size", "expected", 4, "got", column.LenSize)
+	}
+
+	body := []byte{
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+		0x00, 0x00, // flags
+		0x01, 0x01, // column count, present bitmap
+		0x00,
+		0x0d, 0x00, 0x00, 0x00,
+		0x00, 0x01, 0x00, 0x0c, 0x00, 0x0b, 0x00, 0x01, 0x00, 0x05, 0x01, 0x00, 0x61,
+		0x00,
+		0x03, 0x00, 0x00, 0x00,
+		0x0c, 0x01, 0x61,
+	}
+
+	rows := &rowsEvent{
+		eventLogHeader:   &eventLogHeader{EventType: _WRITE_ROWS_EVENTv1},
+		tableMapEvent:    &TableMapEvent{Columns: []*Column{column}},
+		postHeaderLength: 8,
+	}
+
+	if err := rows.read(newPackWithBuff(body)); err != nil {
+		t.Fatal("Read rows event fail", err)
+	}
+
+	for i, expected := range []string{`{"a":1}`, `"a"`} {
+		value, ok := rows.values[i][0].GetValue().(json.RawMessage)
+		if !ok || string(value) != expected {
+			t.Fatal("Incorrect JSON value at row", i, "expected", expected, "got", rows.values[i][0].GetValue())
+		}
+	}
+
+	//a broken document fails the event
+	body[15] = 0x0d
+	rows.values = nil
+	if err := rows.read(newPackWithBuff(body)); err == nil {
+		t.Fatal("Incorrect JSON value accepted")
+	}
+}
+
+func TestPartialUpdateRowsEvent(t *testing.T) {
+	body := []byte{
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+		0x00, 0x00, // flags
+		0x02, 0x00, // extra data length
+		0x02, 0x03, 0x02, // column count, present bitmaps, the after image without id
+		//id 1, {"a":1}
+		0x00, 0x01, 0x00, 0x00, 0x00,
+		0x0d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0c, 0x00, 0x0b, 0x00, 0x01, 0x00, 0x05, 0x01, 0x00, 0x61,
+		//partial JSON updates, doc holds diffs
+		0x01, 0x01, 0x00,
+		0x12, 0x00, 0x00, 0x00,
+		0x00, 0x03, '$', '.', 'a', 0x03, 0x05, 0x02, 0x00,
+		0x01, 0x03, '$', '.', 'b', 0x03, 0x0c, 0x01, 'x',
+		//id 2, {"a":1}
+		0x00, 0x02, 0x00, 0x00, 0x00,
+		0x0d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0c, 0x00, 0x0b, 0x00, 0x01, 0x00, 0x05, 0x01, 0x00, 0x61,
+		//no value options, the whole document
+		0x00, 0x00,
+		0x03, 0x00, 0x00, 0x00, 0x0c, 0x01, 'y',
+	}
+
+	rows := &rowsEvent{
+		eventLogHeader: &eventLogHeader{EventType: _PARTIAL_UPDATE_ROWS_EVENT},
+		tableMapEvent: &TableMapEvent{Columns: []*Column{
+			&Column{Type: MYSQL_TYPE_LONG},
+			&Column{Type: MYSQL_TYPE_JSON, LenSize: 4},
+		}},
+		postHeaderLength: 8,
+	}
+
+	if err := rows.read(newPackWithBuff(body)); err != nil {
+		t.Fatal("Read rows event fail", err)
+	}
+
+	event := &UpdateEvent{rows}
+	newRows := event.GetNewRows()
+	if len(event.GetRows()) != 2 || len(newRows) != 2 || len(newRows[0]) != 1 || len(newRows[1]) != 1 {
+		t.Fatal("Incorrect rows", "expected", "2 rows of 2 and 1 columns", "got", event.GetRows(), newRows)
+	}
+
+	expectedDiffs := []*JSONDiff{
+		&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.a", Value: json.RawMessage(`2`)},
+		&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.b", Value: json.RawMessage(`"x"`)},
+	}
+
+	if !newRows[0][0].IsPartial() || newRows[0][0].GetColumnId() != 1 || !reflect.DeepEqual(newRows[0][0].GetValue(), expectedDiffs) {
+		t.Fatal("Incorrect partial value", "expected", expectedDiffs, "got", newRows[0][0].GetValue())
+	}
+
+	if newRows[1][0].IsPartial() {
+		t.Fatal("Incorrect partial value", "expected", `"y"`, "got", newRows[1][0].GetValue())
+	}
+
+	fullRows, err := event.GetFullNewRows()
+	if err != nil {
+		t.Fatal("Apply JSON diffs fail", err)
+	}
+
+	for i, expected := range []string{`{"a":2,"b":"x"}`, `"y"`} {
+		value, ok := fullRows[i][0].GetValue().(json.RawMessage)
+		if !ok || string(value) != expected || fullRows[i][0].IsPartial() {
+			t.Fatal("Incorrect full value at row", i, "expected", expected, "got", fullRows[i][0].GetValue())
+		}
+	}
+
+	//the diffs need the document of the before image
+	rows.values[0][1].value = nil
+	rows.values[0][1].isNull = true
+	if _, err := event.GetFullNewRows(); err == nil {
+		t.Fatal("Incorrect JSON diffs applied to null")
+	}
+}
+
+func TestRowsEventTruncated(t *testing.T) {
+	for i, testCase := range rowsEventTestCases() {
+		//the packet and the event lose their last byte
+		buff := testCase.rowsEventBuff
+		buff[0]--
+		pack, header := readFuzzEvent(buff[:len(buff)-1])
+		if pack == nil {
+			t.Fatal("Read event header fail at test", i)
+		}
+
+		rows := &rowsEvent{
+			eventLogHeader:   header,
+			tableMapEvent:    testCase.getTableMapEvent(),
+			postHeaderLength: 8,
+		}
+
+		if err := rows.read(pack); err == nil {
+			t.Fatal("Incorrect truncated rows event at test", i, "expected", "error", "got", nil)
+		}
+	}
+}
+
+// readFuzzEvent reads the packet and event header of data, nil when either
+// is incorrect
+func readFuzzEvent(data []byte) (*pack, *eventLogHeader) {
+	pack, err := newPackReader(bytes.NewBuffer(data)).readNextPack()
+	if err != nil {
+		return nil, nil
+	}
+
+	header := &eventLogHeader{}
+	if err = header.readHead(pack); err != nil {
+		return nil, nil
+	}
+	return pack, header
+}
+
+func rowsEventTestCases() []*rowEventTestCase {
+	testCases := writeRowsEventTestCases()
+	testCases = append(testCases, deleteRowsEventTestCases()...)
+	return append(testCases, updateRowsEventTestCases()...)
+}
+
+func FuzzTableMapEvent(f *testing.F) {
+	f.Add(typesTableMapEvent)
+	for _, testCase := range rowsEventTestCases() {
+		f.Add(testCase.tableMapEventBuff)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pack, header := readFuzzEvent(data)
+		if pack == nil {
+			return
+		}
+
+		table := &TableMapEvent{eventLogHeader: header}
+		table.read(pack)
+	})
+}
+
+func FuzzRowsEvent(f *testing.F) {
+	for _, testCase := range rowsEventTestCases() {
+		f.Add(testCase.tableMapEventBuff, testCase.rowsEventBuff)
+	}
+
+	f.Fuzz(func(t *testing.T, tableMapData, rowsData []byte) {
+		tablePack, tableHeader := readFuzzEvent(tableMapData)
+		if tablePack == nil {
+			return
+		}
+
+		table := &TableMapEvent{eventLogHeader: tableHeader}
+		if err := table.read(tablePack); err != nil {
+			return
+		}
+
+		pack, header := readFuzzEvent(rowsData)
+		if pack == nil {
+			return
+		}
+
+		rows := &rowsEvent{
+			eventLogHeader:   header,
+			tableMapEvent:    table,
+			postHeaderLength: 8,
+		}
+		rows.read(pack)
+	})
+}
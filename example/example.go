@@ -1,44 +1,58 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/wangjild/myreplication"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
-var (
-	host     = "10.4.16.17"
-	port     = 3307
-	username = "repl"
-	password = "replmtc50"
-)
-
+// REPLICATION_DSN example: repl:secret@tcp(10.4.16.17:3307)/?serverId=16173307&timeout=5s
 func main() {
+	cfg, err := myreplication.ParseDSN(os.Getenv("REPLICATION_DSN"))
+
+	if err != nil {
+		panic("Incorrect REPLICATION_DSN: " + err.Error())
+	}
+
 	newConnection := myreplication.NewConnection()
-	serverId := uint32(16173307)
-	err := newConnection.ConnectAndAuth(host, port, username, password)
+	err = newConnection.Connect(cfg)
 
 	if err != nil {
 		panic("Client not connected and not autentificate to master server with error:" + err.Error())
 	}
-	//Get position and file name
-	/*
-		pos, filename, err := newConnection.GetMasterStatus()
+	//Get position and file name, the stream starts there unless a checkpoint is saved
+	pos, filename, err := newConnection.GetMasterStatus()
+	newConnection.Close()
 
-		if err != nil {
-			panic("Master status fail: " + err.Error())
-		}*/
+	if err != nil {
+		panic("Master status fail: " + err.Error())
+	}
+
+	checkpointPath := os.Getenv("REPLICATION_CHECKPOINT")
+	if checkpointPath == "" {
+		checkpointPath = "replication.checkpoint"
+	}
 
-	//	pos, filename := 2549710, "mysql-bin.155900"
-	pos, filename := 14904245, "mysql-bin.160668"
-	el, err := newConnection.StartBinlogDump(uint32(pos), filename, serverId)
+	streamer := myreplication.NewStreamer(cfg, filename, pos)
+	err = streamer.SetCheckpointStore(myreplication.NewFileCheckpointStore(checkpointPath))
 
 	if err != nil {
-		panic("Cant start bin log: " + err.Error())
+		panic("Cant load checkpoint: " + err.Error())
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	for {
-		event, err := el.GetEvent()
+		event, err := streamer.GetEvent(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				streamer.Close()
+				return
+			}
 			panic(err.Error())
 		}
 
@@ -48,6 +62,9 @@ func main() {
 			if e.GetQuery() != "BEGIN" {
 				println("Query: " + e.GetQuery())
 			}
+		case *myreplication.GtidEvent:
+			//Output gtid of the next transaction
+			println("GTID: " + e.GetGTID())
 		case *myreplication.IntVarEvent:
 			//Output last insert_id  if statement based replication
 			println(e.GetValue())
@@ -91,6 +108,11 @@ func main() {
 			println("ExecuteLoad", e.GetQuery())
 		default:
 		}
+
+		//Save the end of the last complete transaction
+		if err = streamer.Commit(); err != nil {
+			panic("Cant save checkpoint: " + err.Error())
+		}
 	}
 
 }
@@ -0,0 +1,165 @@
+package myreplication
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type (
+	// TableFilter selects the tables whose events GetEvent returns. A name
+	// pattern is an exact name, a glob like "log_*" or a regular expression
+	// between slashes like "/^log_[0-9]+$/", empty or "*" matches any name.
+	// Exclude rules win over include rules, with no include rule every table
+	// not excluded passes.
+	TableFilter struct {
+		include []*tableRule
+		exclude []*tableRule
+	}
+
+	tableRule struct {
+		schema *namePattern
+		table  *namePattern
+	}
+
+	namePattern struct {
+		any    bool
+		exact  string
+		glob   string
+		regexp *regexp.Regexp
+	}
+)
+
+var (
+	INVALID_TABLE_FILTER_ERR = errors.New("invalid table filter pattern")
+)
+
+func NewTableFilter() *TableFilter {
+	return &TableFilter{}
+}
+
+// Include adds the tables matching both patterns
+func (f *TableFilter) Include(schema, table string) error {
+	rule, err := newTableRule(schema, table)
+	if err != nil {
+		return err
+	}
+
+	f.include = append(f.include, rule)
+	return nil
+}
+
+// Exclude removes the tables matching both patterns, Exclude(schema, "*")
+// also removes the queries run in the schema
+func (f *TableFilter) Exclude(schema, table string) error {
+	rule, err := newTableRule(schema, table)
+	if err != nil {
+		return err
+	}
+
+	f.exclude = append(f.exclude, rule)
+	return nil
+}
+
+func (f *TableFilter) Match(schema, table string) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, rule := range f.exclude {
+		if rule.schema.match(schema) && rule.table.match(table) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, rule := range f.include {
+		if rule.schema.match(schema) && rule.table.match(table) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchSchema is true when some tables of the schema may pass the filter,
+// queries are filtered by the default schema they run in
+func (f *TableFilter) MatchSchema(schema string) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, rule := range f.exclude {
+		if rule.table.any && rule.schema.match(schema) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, rule := range f.include {
+		if rule.schema.match(schema) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTableRule(schema, table string) (*tableRule, error) {
+	schemaPattern, err := newNamePattern(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tablePattern, err := newNamePattern(table)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tableRule{schemaPattern, tablePattern}, nil
+}
+
+func newNamePattern(pattern string) (*namePattern, error) {
+	switch {
+	case pattern == "" || pattern == "*":
+		return &namePattern{any: true}, nil
+	case len(pattern) > 1 && pattern[0] == '/' && pattern[len(pattern)-1] == '/':
+		expression, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", INVALID_TABLE_FILTER_ERR.Error(), pattern)
+		}
+		return &namePattern{regexp: expression}, nil
+	case strings.ContainsAny(pattern, "*?["):
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: %s", INVALID_TABLE_FILTER_ERR.Error(), pattern)
+		}
+		return &namePattern{glob: pattern}, nil
+	}
+
+	return &namePattern{exact: pattern}, nil
+}
+
+func (p *namePattern) match(name string) bool {
+	switch {
+	case p.any:
+		return true
+	case p.regexp != nil:
+		return p.regexp.MatchString(name)
+	case p.glob != "":
+		matched, _ := path.Match(p.glob, name)
+		return matched
+	}
+	return p.exact == name
+}
+
+// SetTableFilter skips the events of the tables filter does not match, their
+// rows are not decoded and their columns are not queried from the master
+func (ev *EventLog) SetTableFilter(filter *TableFilter) {
+	ev.tableFilter = filter
+}
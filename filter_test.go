@@ -0,0 +1,133 @@
+package myreplication
+
+import (
+	"testing"
+)
+
+func standInTableMapBody(tableId uint64, schema, table string) []byte {
+	body := make([]byte, 8)
+	writeUInt64(body, tableId)
+	body = append(body[:6], 0, 0)
+	body = append(body, byte(len(schema)))
+	body = append(body, []byte(schema)...)
+	body = append(body, 0, byte(len(table)))
+	body = append(body, []byte(table)...)
+	//one int column without metadata, not nullable
+	return append(body, 0, 1, MYSQL_TYPE_LONG, 0, 0)
+}
+
+func standInWriteRowsBody(tableId uint64, value uint32) []byte {
+	body := make([]byte, 8)
+	writeUInt64(body, tableId)
+	body = append(body[:6], 0, 0)
+	//one column, present, not null
+	body = append(body, 1, 0x01, 0x00)
+	row := make([]byte, 4)
+	writeUInt32(row, value)
+	return append(body, row...)
+}
+
+func TestTableFilter(t *testing.T) {
+	filter := NewTableFilter()
+	filter.Include("shop", "*")
+	filter.Include("/^log_[0-9]+$/", "events")
+	filter.Exclude("shop", "tmp_*")
+	filter.Exclude("shop_archive", "")
+
+	type filterTestCase struct {
+		schema string
+		table  string
+		match  bool
+	}
+
+	testCases := []*filterTestCase{
+		&filterTestCase{"shop", "orders", true},
+		&filterTestCase{"shop", "tmp_orders", false},
+		&filterTestCase{"shopping", "orders", false},
+		&filterTestCase{"log_2024", "events", true},
+		&filterTestCase{"log_2024", "users", false},
+		&filterTestCase{"log_old", "events", false},
+		&filterTestCase{"shop_archive", "orders", false},
+	}
+
+	for i, testCase := range testCases {
+		if filter.Match(testCase.schema, testCase.table) != testCase.match {
+			t.Fatal(
+				"Incorrect match at test", i,
+				"expected", testCase.match,
+				"got", !testCase.match,
+			)
+		}
+	}
+
+	schemaTestCases := []*filterTestCase{
+		&filterTestCase{"shop", "", true},
+		&filterTestCase{"log_1", "", true},
+		&filterTestCase{"shop_archive", "", false},
+		&filterTestCase{"mysql", "", false},
+	}
+
+	for i, testCase := range schemaTestCases {
+		if filter.MatchSchema(testCase.schema) != testCase.match {
+			t.Fatal(
+				"Incorrect schema match at test", i,
+				"expected", testCase.match,
+				"got", !testCase.match,
+			)
+		}
+	}
+
+	var empty *TableFilter
+	if !empty.Match("any", "table") || !NewTableFilter().MatchSchema("any") {
+		t.Fatal("Incorrect match of an empty filter")
+	}
+
+	for _, pattern := range []string{"/(/", "log_["} {
+		if err := NewTableFilter().Include(pattern, ""); err == nil {
+			t.Fatal("Incorrect pattern accepted", pattern)
+		}
+	}
+}
+
+func TestEventLogTableFilter(t *testing.T) {
+	connection, server := newPipeConnection()
+	defer server.Close()
+
+	//the connection has no control connection, a schema lookup of a
+	//filtered table would fail
+	el := newEventLog(connection, 0)
+	filter := NewTableFilter()
+	filter.Include("shop", "")
+	el.SetTableFilter(filter)
+
+	go writeStandInEvents(server,
+		standInEvent(_QUERY_EVENT, 100, standInQueryBody("audit", "BEGIN")),
+		standInEvent(_TABLE_MAP_EVENT, 100, standInTableMapBody(42, "audit", "log")),
+		standInEvent(_WRITE_ROWS_EVENTv1, 100, standInWriteRowsBody(42, 7)),
+		standInXidEvent(10),
+		standInEvent(_QUERY_EVENT, 100, standInQueryBody("audit", "TRUNCATE log")),
+		standInEvent(_QUERY_EVENT, 100, standInQueryBody("shop", "CREATE TABLE t (id int)")),
+	)
+
+	expected := []string{"BEGIN", "CREATE TABLE t (id int)"}
+
+	for i, query := range expected {
+		event, err := el.GetEvent()
+		if err != nil {
+			t.Fatal("Get event fail at", i, err)
+		}
+
+		queryEvent, ok := event.(*QueryEvent)
+		if !ok || queryEvent.GetQuery() != query {
+			t.Fatal(
+				"Incorrect event at", i,
+				"expected", query,
+				"got", event,
+			)
+		}
+	}
+
+	if len(el.tableMap) != 0 {
+		t.Fatal("Incorrect table map", "expected", 0, "got", len(el.tableMap))
+	}
+}
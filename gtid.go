@@ -0,0 +1,293 @@
+package myreplication
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	_BINLOG_THROUGH_GTID = 0x04
+)
+
+type (
+	// GTIDSet is a set of global transaction identifiers in the
+	// uuid:1-5:7,uuid:1-3 form used by gtid_executed
+	GTIDSet struct {
+		sids map[[16]byte][]gtidInterval
+	}
+
+	// gtidInterval holds transaction numbers from start up to but not including end
+	gtidInterval struct {
+		start int64
+		end   int64
+	}
+)
+
+var (
+	INVALID_GTID_SET_ERR = errors.New("invalid gtid set")
+)
+
+func NewGTIDSet() *GTIDSet {
+	return &GTIDSet{
+		sids: map[[16]byte][]gtidInterval{},
+	}
+}
+
+// ParseGTIDSet parses the text form of a gtid set, empty string is the empty set
+func ParseGTIDSet(s string) (*GTIDSet, error) {
+	set := NewGTIDSet()
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return set, nil
+	}
+
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("%s: %s", INVALID_GTID_SET_ERR.Error(), item)
+		}
+
+		sid, err := parseSid(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, part := range parts[1:] {
+			interval, err := parseGTIDInterval(part)
+			if err != nil {
+				return nil, err
+			}
+			set.addInterval(sid, interval)
+		}
+	}
+
+	return set, nil
+}
+
+func parseSid(s string) (sid [16]byte, err error) {
+	s = strings.TrimSpace(s)
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return sid, fmt.Errorf("%s: incorrect uuid %s", INVALID_GTID_SET_ERR.Error(), s)
+	}
+
+	data, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil {
+		return sid, fmt.Errorf("%s: incorrect uuid %s", INVALID_GTID_SET_ERR.Error(), s)
+	}
+
+	copy(sid[:], data)
+	return sid, nil
+}
+
+func formatSid(sid [16]byte) string {
+	s := hex.EncodeToString(sid[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func parseGTIDInterval(s string) (gtidInterval, error) {
+	bounds := strings.SplitN(s, "-", 2)
+
+	start, err := strconv.ParseInt(bounds[0], 10, 64)
+	if err != nil || start < 1 {
+		return gtidInterval{}, fmt.Errorf("%s: incorrect interval %s", INVALID_GTID_SET_ERR.Error(), s)
+	}
+
+	end := start
+	if len(bounds) == 2 {
+		end, err = strconv.ParseInt(bounds[1], 10, 64)
+		if err != nil || end < start {
+			return gtidInterval{}, fmt.Errorf("%s: incorrect interval %s", INVALID_GTID_SET_ERR.Error(), s)
+		}
+	}
+
+	return gtidInterval{start, end + 1}, nil
+}
+
+// String formats the set like mysql does, sids and intervals in ascending order
+func (g *GTIDSet) String() string {
+	sids := g.sortedSids()
+	items := make([]string, 0, len(sids))
+
+	for _, sid := range sids {
+		item := formatSid(sid)
+		for _, interval := range g.sids[sid] {
+			if interval.end-interval.start == 1 {
+				item += ":" + strconv.FormatInt(interval.start, 10)
+			} else {
+				item += ":" + strconv.FormatInt(interval.start, 10) + "-" + strconv.FormatInt(interval.end-1, 10)
+			}
+		}
+		items = append(items, item)
+	}
+
+	return strings.Join(items, ",")
+}
+
+func (g *GTIDSet) sortedSids() [][16]byte {
+	sids := make([][16]byte, 0, len(g.sids))
+	for sid := range g.sids {
+		sids = append(sids, sid)
+	}
+
+	sort.Slice(sids, func(i, j int) bool {
+		return bytes.Compare(sids[i][:], sids[j][:]) < 0
+	})
+
+	return sids
+}
+
+func (g *GTIDSet) IsEmpty() bool {
+	return len(g.sids) == 0
+}
+
+func (g *GTIDSet) Clone() *GTIDSet {
+	clone := NewGTIDSet()
+	for sid, intervals := range g.sids {
+		clone.sids[sid] = append([]gtidInterval(nil), intervals...)
+	}
+	return clone
+}
+
+func (g *GTIDSet) Equal(other *GTIDSet) bool {
+	return g.Contains(other) && other.Contains(g)
+}
+
+// AddGTID adds one transaction, sid is the server uuid
+func (g *GTIDSet) AddGTID(sid string, gno int64) error {
+	parsedSid, err := parseSid(sid)
+	if err != nil {
+		return err
+	}
+	if gno < 1 {
+		return fmt.Errorf("%s: incorrect transaction number %d", INVALID_GTID_SET_ERR.Error(), gno)
+	}
+
+	g.addInterval(parsedSid, gtidInterval{gno, gno + 1})
+	return nil
+}
+
+// Union adds all transactions of other to the set
+func (g *GTIDSet) Union(other *GTIDSet) {
+	for sid, intervals := range other.sids {
+		for _, interval := range intervals {
+			g.addInterval(sid, interval)
+		}
+	}
+}
+
+// Contains is true when every transaction of other is in the set
+func (g *GTIDSet) Contains(other *GTIDSet) bool {
+	for sid, intervals := range other.sids {
+		for _, interval := range intervals {
+			if !g.containsInterval(sid, interval) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (g *GTIDSet) ContainsGTID(sid string, gno int64) bool {
+	parsedSid, err := parseSid(sid)
+	if err != nil {
+		return false
+	}
+	return g.containsInterval(parsedSid, gtidInterval{gno, gno + 1})
+}
+
+func (g *GTIDSet) containsInterval(sid [16]byte, interval gtidInterval) bool {
+	for _, current := range g.sids[sid] {
+		if current.start <= interval.start && interval.end <= current.end {
+			return true
+		}
+	}
+	return false
+}
+
+// addInterval keeps intervals sorted and merges the adjacent ones
+func (g *GTIDSet) addInterval(sid [16]byte, interval gtidInterval) {
+	intervals := g.sids[sid]
+	merged := make([]gtidInterval, 0, len(intervals)+1)
+
+	i := 0
+	for ; i < len(intervals) && intervals[i].end < interval.start; i++ {
+		merged = append(merged, intervals[i])
+	}
+
+	for ; i < len(intervals) && intervals[i].start <= interval.end; i++ {
+		if intervals[i].start < interval.start {
+			interval.start = intervals[i].start
+		}
+		if intervals[i].end > interval.end {
+			interval.end = intervals[i].end
+		}
+	}
+
+	merged = append(merged, interval)
+	g.sids[sid] = append(merged, intervals[i:]...)
+}
+
+// encode writes the set in the binary form of COM_BINLOG_DUMP_GTID and
+// PREVIOUS_GTIDS_EVENT
+func (g *GTIDSet) encode() []byte {
+	sids := g.sortedSids()
+
+	buff := make([]byte, 8)
+	writeUInt64(buff, uint64(len(sids)))
+
+	for _, sid := range sids {
+		buff = append(buff, sid[:]...)
+
+		number := make([]byte, 8)
+		writeUInt64(number, uint64(len(g.sids[sid])))
+		buff = append(buff, number...)
+
+		for _, interval := range g.sids[sid] {
+			writeUInt64(number, uint64(interval.start))
+			buff = append(buff, number...)
+			writeUInt64(number, uint64(interval.end))
+			buff = append(buff, number...)
+		}
+	}
+
+	return buff
+}
+
+func decodeGTIDSet(data []byte) (*GTIDSet, error) {
+	set := NewGTIDSet()
+	pack := newPackWithBuff(data)
+
+	var sidsCount uint64
+	if err := pack.readUint64(&sidsCount); err != nil {
+		return nil, INVALID_GTID_SET_ERR
+	}
+
+	for i := uint64(0); i < sidsCount; i++ {
+		var sid [16]byte
+		if pack.Len() < len(sid) {
+			return nil, INVALID_GTID_SET_ERR
+		}
+		copy(sid[:], pack.Next(len(sid)))
+
+		var intervalsCount uint64
+		if err := pack.readUint64(&intervalsCount); err != nil {
+			return nil, INVALID_GTID_SET_ERR
+		}
+
+		for j := uint64(0); j < intervalsCount; j++ {
+			var start, end uint64
+			if pack.readUint64(&start) != nil || pack.readUint64(&end) != nil || start < 1 || end <= start {
+				return nil, INVALID_GTID_SET_ERR
+			}
+			set.addInterval(sid, gtidInterval{int64(start), int64(end)})
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,136 @@
+package myreplication
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGTIDSet(t *testing.T) {
+	type gtidTestCase struct {
+		set      string
+		expected string
+	}
+
+	testCases := []*gtidTestCase{
+		&gtidTestCase{"", ""},
+		&gtidTestCase{"3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"},
+		&gtidTestCase{"3e11fa47-71ca-11e1-9e33-c80aa9429562:7:1-3:4-5:9-9", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:7:9"},
+		&gtidTestCase{
+			"fc3f0d1c-71ca-11e1-9e33-c80aa9429562:1-2,\n3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,fc3f0d1c-71ca-11e1-9e33-c80aa9429562:1-2",
+		},
+		&gtidTestCase{"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-3,3e11fa47-71ca-11e1-9e33-c80aa9429562:2-8", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8"},
+	}
+
+	for i, testCase := range testCases {
+		set, err := ParseGTIDSet(testCase.set)
+		if err != nil {
+			t.Fatal("Parse gtid set fail at test", i, err)
+		}
+
+		if set.String() != testCase.expected {
+			t.Fatal(
+				"Incorrect gtid set at test", i,
+				"expected", testCase.expected,
+				"got", set.String(),
+			)
+		}
+
+		decoded, err := decodeGTIDSet(set.encode())
+		if err != nil {
+			t.Fatal("Decode gtid set fail at test", i, err)
+		}
+
+		if !decoded.Equal(set) {
+			t.Fatal(
+				"Incorrect decoded gtid set at test", i,
+				"expected", set,
+				"got", decoded,
+			)
+		}
+	}
+}
+
+func TestParseGTIDSetError(t *testing.T) {
+	testCases := []string{
+		"3e11fa47-71ca-11e1-9e33-c80aa9429562",
+		"3e11fa47-71ca-11e1-9e33:1-5",
+		"3e11fa47-71ca-11e1-9e33-c80aa942956z:1-5",
+		"3e11fa47-71ca-11e1-9e33-c80aa9429562:0-5",
+		"3e11fa47-71ca-11e1-9e33-c80aa9429562:5-1",
+		"3e11fa47-71ca-11e1-9e33-c80aa9429562:a",
+	}
+
+	for i, set := range testCases {
+		if _, err := ParseGTIDSet(set); err == nil {
+			t.Fatal("Expected gtid set error at test", i, set)
+		}
+	}
+}
+
+func TestGTIDSetUnionContains(t *testing.T) {
+	set, _ := ParseGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5")
+	other, _ := ParseGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:6-7,fc3f0d1c-71ca-11e1-9e33-c80aa9429562:2")
+
+	if set.Contains(other) || !set.Contains(NewGTIDSet()) {
+		t.Fatal("Incorrect contains before union")
+	}
+
+	set.Union(other)
+
+	expected := "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-7,fc3f0d1c-71ca-11e1-9e33-c80aa9429562:2"
+	if set.String() != expected {
+		t.Fatal(
+			"Incorrect union",
+			"expected", expected,
+			"got", set.String(),
+		)
+	}
+
+	if !set.Contains(other) || other.Contains(set) {
+		t.Fatal("Incorrect contains after union")
+	}
+
+	if !set.ContainsGTID("FC3F0D1C-71CA-11E1-9E33-C80AA9429562", 2) || set.ContainsGTID("fc3f0d1c-71ca-11e1-9e33-c80aa9429562", 1) {
+		t.Fatal("Incorrect contains gtid")
+	}
+
+	if err := set.AddGTID("fc3f0d1c-71ca-11e1-9e33-c80aa9429562", 1); err != nil {
+		t.Fatal("Add gtid fail", err)
+	}
+
+	expected = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-7,fc3f0d1c-71ca-11e1-9e33-c80aa9429562:1-2"
+	if set.String() != expected {
+		t.Fatal(
+			"Incorrect set after add",
+			"expected", expected,
+			"got", set.String(),
+		)
+	}
+}
+
+func TestGTIDSetEncode(t *testing.T) {
+	set, _ := ParseGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:7")
+
+	expected := []byte{
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	if !reflect.DeepEqual(expected, set.encode()) {
+		t.Fatal(
+			"Incorrect encoded gtid set",
+			"expected", expected,
+			"got", set.encode(),
+		)
+	}
+
+	if _, err := decodeGTIDSet(expected[:30]); err == nil {
+		t.Fatal("Expected error for truncated gtid set")
+	}
+}
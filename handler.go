@@ -0,0 +1,137 @@
+package myreplication
+
+import (
+	"context"
+)
+
+type (
+	// RowsEvent is implemented by WriteEvent, UpdateEvent and DeleteEvent
+	RowsEvent interface {
+		Event
+		GetSchema() string
+		GetTable() string
+		GetRows() [][]*RowsEventValue
+		GetColumns() []*Column
+	}
+
+	// EventHandler holds the callbacks of Run, the events of a nil callback
+	// are skipped. OnEvent gets the events without a callback of their own.
+	// An error returned by a callback stops Run.
+	EventHandler struct {
+		OnRow    func(event RowsEvent) error
+		OnQuery  func(event *QueryEvent) error
+		OnRotate func(next Position) error
+		OnXid    func(event *XidEvent) error
+		OnGTID   func(event *GtidEvent) error
+		OnEvent  func(event Event) error
+	}
+
+	// EventStream delivers the events of Stream through a channel
+	EventStream struct {
+		events chan Event
+		done   chan struct{}
+		err    error
+	}
+)
+
+// Run reads the events and passes them to the callbacks of handler until
+// ctx is done, the stream fails or a callback returns an error
+func (ev *EventLog) Run(ctx context.Context, handler *EventHandler) (err error) {
+	stop := ev.mysqlConnection.watchContext(ctx)
+	defer func() {
+		stop()
+		err = contextError(ctx, err)
+	}()
+
+	for {
+		event, err := ev.nextEvent(handler.OnXid != nil, handler.OnRotate != nil)
+		if err != nil {
+			return err
+		}
+
+		if err = handler.handle(event); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *EventHandler) handle(event Event) error {
+	switch e := event.(type) {
+	case RowsEvent:
+		if h.OnRow != nil {
+			return h.OnRow(e)
+		}
+	case *QueryEvent:
+		if h.OnQuery != nil {
+			return h.OnQuery(e)
+		}
+	case *logRotateEvent:
+		if h.OnRotate != nil {
+			return h.OnRotate(Position{string(e.binlogFileName), uint32(e.position)})
+		}
+	case *XidEvent:
+		if h.OnXid != nil {
+			return h.OnXid(e)
+		}
+	case *GtidEvent:
+		if h.OnGTID != nil {
+			return h.OnGTID(e)
+		}
+	default:
+		if h.OnEvent != nil {
+			return h.OnEvent(e)
+		}
+	}
+	return nil
+}
+
+// Stream reads the events in a goroutine into a channel of buffer events,
+// reading stops while the channel is full. The channel is closed when ctx is
+// done or the stream fails, Err returns the reason then.
+func (ev *EventLog) Stream(ctx context.Context, buffer int) *EventStream {
+	stream := &EventStream{
+		events: make(chan Event, buffer),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(stream.done)
+		defer close(stream.events)
+
+		for {
+			event, err := ev.GetEventContext(ctx)
+			if err != nil {
+				stream.err = err
+				return
+			}
+
+			select {
+			case stream.events <- event:
+			case <-ctx.Done():
+				stream.err = ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return stream
+}
+
+func (s *EventStream) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed with the events channel
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the stream, nil while it runs
+func (s *EventStream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
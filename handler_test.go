@@ -0,0 +1,107 @@
+package myreplication
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 0)
+
+	sid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+
+	go writeStandInEvents(server,
+		standInEvent(_ROTATE_EVENT, 0, standInRotateBody("mysql-bin.000002", 4)),
+		standInGtidEvent(sid, 3),
+		standInQueryEvent("BEGIN"),
+		standInIntVarEvent(1),
+		standInXidEvent(10),
+		standInQueryEvent("COMMIT"),
+	)
+
+	stopErr := errors.New("stop")
+	var got []string
+
+	handler := &EventHandler{
+		OnQuery: func(event *QueryEvent) error {
+			got = append(got, event.GetQuery())
+			if event.GetQuery() == "COMMIT" {
+				return stopErr
+			}
+			return nil
+		},
+		OnRotate: func(next Position) error {
+			got = append(got, next.File)
+			return nil
+		},
+		OnXid: func(event *XidEvent) error {
+			got = append(got, "xid")
+			return nil
+		},
+		OnGTID: func(event *GtidEvent) error {
+			got = append(got, event.GetGTID())
+			return nil
+		},
+		OnEvent: func(event Event) error {
+			if _, ok := event.(*IntVarEvent); ok {
+				got = append(got, "intvar")
+			}
+			return nil
+		},
+	}
+
+	if err := el.Run(context.Background(), handler); err != stopErr {
+		t.Fatal("Incorrect run error", "expected", stopErr, "got", err)
+	}
+
+	expected := []string{"mysql-bin.000002", sid + ":3", "BEGIN", "intvar", "xid", "COMMIT"}
+	if len(got) != len(expected) {
+		t.Fatal("Incorrect events count", "expected", expected, "got", got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatal("Incorrect event at", i, "expected", expected[i], "got", got[i])
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	connection, server := newPipeConnection()
+	el := newEventLog(connection, 0)
+
+	go func() {
+		writeStandInEvents(server,
+			standInQueryEvent("CREATE TABLE t (id int)"),
+			standInQueryEvent("DROP TABLE t"),
+		)
+		server.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream := el.Stream(ctx, 1)
+
+	expected := []string{"CREATE TABLE t (id int)", "DROP TABLE t"}
+	i := 0
+	for event := range stream.Events() {
+		if i >= len(expected) || event.(*QueryEvent).GetQuery() != expected[i] {
+			t.Fatal("Incorrect event at", i, "got", event)
+		}
+		i++
+	}
+
+	if i != len(expected) {
+		t.Fatal("Incorrect events count", "expected", len(expected), "got", i)
+	}
+
+	<-stream.Done()
+	if stream.Err() == nil {
+		t.Fatal("Incorrect stream error", "expected", "connection error", "got", nil)
+	}
+}
@@ -4,7 +4,9 @@ package myreplication
 	http://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::HandshakeV10
 */
 
-import ()
+import (
+	"fmt"
+)
 
 type (
 	pkgHandshake struct {
@@ -16,13 +18,18 @@ type (
 		status_flags     uint16
 		auth_plugin_data []byte
 		auth_plugin_name []byte
+		ssl              bool
+		unixSocket       bool
+		auth             *authenticator
 	}
 )
 
 func (h *pkgHandshake) readServer(r *pack) (err error) {
-	r.readByte(&h.protocol_version)
+	if err = r.readByte(&h.protocol_version); err != nil {
+		return
+	}
 	if h.protocol_version != _HANDSHAKE_VERSION_10 {
-		panic("Support only HandshakeV10")
+		return fmt.Errorf("unsupported handshake protocol version %d, only HandshakeV10 is supported", h.protocol_version)
 	}
 
 	h.server_version, err = r.readNilString()
@@ -55,7 +62,7 @@ func (h *pkgHandshake) readServer(r *pack) (err error) {
 	var capSecond uint16
 	r.readUint16(&capSecond)
 
-	h.capabilities = h.capabilities | (uint32(capSecond) << 8)
+	h.capabilities = h.capabilities | (uint32(capSecond) << 16)
 
 	lengthAuthPluginData, _ := r.Buffer.ReadByte()
 
@@ -77,6 +84,8 @@ func (h *pkgHandshake) readServer(r *pack) (err error) {
 		}
 
 		h.auth_plugin_data = append(h.auth_plugin_data, auth_plugin_data_2...)
+		//skip auth-plugin-data-part-2 terminator
+		r.Buffer.ReadByte()
 	}
 
 	if h.capabilities&_CLIENT_PLUGIN_AUTH == _CLIENT_PLUGIN_AUTH {
@@ -89,12 +98,19 @@ func (h *pkgHandshake) readServer(r *pack) (err error) {
 func (h *pkgHandshake) writeServer(username, password string) *pack {
 	var encPasswd []byte = []byte{}
 
+	h.auth = &authenticator{
+		plugin:   h.authPluginName(),
+		scramble: h.auth_plugin_data,
+		password: password,
+		secure:   h.ssl || h.unixSocket,
+	}
+
 	if h.capabilities&_CLIENT_SECURE_CONNECTION == _CLIENT_SECURE_CONNECTION {
-		encPasswd = encryptedPasswd(password, h.auth_plugin_data)
+		encPasswd, _ = h.auth.initialResponse()
 	}
 
 	pack := newPack()
-	pack.writeUInt32(_CLIENT_ALL_FLAGS)
+	pack.writeUInt32(h.clientFlags())
 	pack.writeUInt32(_MAX_PACK_SIZE)
 	pack.WriteByte(h.character_set)
 	pack.Write(make([]byte, 23, 23))
@@ -105,5 +121,41 @@ func (h *pkgHandshake) writeServer(username, password string) *pack {
 		pack.Write(encPasswd)
 	}
 
+	if h.capabilities&_CLIENT_PLUGIN_AUTH == _CLIENT_PLUGIN_AUTH {
+		pack.writeStringNil(h.auth.plugin)
+	}
+
+	return pack
+}
+
+// authPluginName falls back to mysql_native_password for unknown plugins,
+// the server answers with an AuthSwitchRequest in that case
+func (h *pkgHandshake) authPluginName() string {
+	switch name := string(h.auth_plugin_name); name {
+	case _AUTH_CACHING_SHA2_PASSWORD, _AUTH_SHA256_PASSWORD:
+		return name
+	}
+
+	return _AUTH_NATIVE_PASSWORD
+}
+
+// http://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::SSLRequest
+func (h *pkgHandshake) writeSSLRequest() *pack {
+	pack := newPack()
+	pack.writeUInt32(h.clientFlags())
+	pack.writeUInt32(_MAX_PACK_SIZE)
+	pack.WriteByte(h.character_set)
+	pack.Write(make([]byte, 23, 23))
 	return pack
 }
+
+func (h *pkgHandshake) clientFlags() uint32 {
+	flags := _CLIENT_ALL_FLAGS
+	if h.capabilities&_CLIENT_PLUGIN_AUTH == _CLIENT_PLUGIN_AUTH {
+		flags |= _CLIENT_PLUGIN_AUTH
+	}
+	if h.ssl {
+		flags |= _CLIENT_SSL
+	}
+	return flags
+}
@@ -78,12 +78,11 @@ func TestHandshakeRead(t *testing.T) {
 		t.Fatal("Incorrect auth plugin data", "expected", string(expectedAuthData), "got", string(handshake.auth_plugin_data))
 	}
 
-	//CLIENT_PLUGIN_AUTH = 0
-	//	expectedAuthPluginName := []byte("mysql_native_password")
-	//
-	//	if !reflect.DeepEqual(handshake.auth_plugin_name, expectedAuthPluginName) {
-	//		t.Fatal("Incorrect auth plugin name", "expected", string(expectedAuthPluginName), "got", string(handshake.auth_plugin_name))
-	//	}
+	expectedAuthPluginName := []byte("mysql_native_password")
+
+	if !reflect.DeepEqual(handshake.auth_plugin_name, expectedAuthPluginName) {
+		t.Fatal("Incorrect auth plugin name", "expected", string(expectedAuthPluginName), "got", string(handshake.auth_plugin_name))
+	}
 }
 
 func TestHandshakeWrite(t *testing.T) {
@@ -204,3 +203,55 @@ func TestHandshakeWrite(t *testing.T) {
 		)
 	}
 }
+
+func TestHandshakeWriteSSLRequest(t *testing.T) {
+	handshake := &pkgHandshake{}
+	handshake.character_set = 0x21
+	handshake.ssl = true
+
+	pack := handshake.writeSSLRequest()
+	pack.setSequence(byte(1))
+	result := pack.packBytes()
+
+	expectedHeader := []byte{0x20, 0x00, 0x00, 0x01}
+
+	if !reflect.DeepEqual(expectedHeader, result[0:4]) {
+		t.Fatal("SSL request header",
+			"expected", expectedHeader,
+			"got", result[0:4],
+		)
+	}
+
+	expectedCapability := []byte{0xD7, 0xFF, 0x03, 0x00}
+
+	if !reflect.DeepEqual(expectedCapability, result[4:8]) {
+		t.Fatal("SSL request capability flags",
+			"expected", expectedCapability,
+			"got", result[4:8],
+		)
+	}
+
+	if handshake.character_set != result[12] {
+		t.Fatal("SSL request charset",
+			"expected", handshake.character_set,
+			"got", result[12],
+		)
+	}
+
+	if !reflect.DeepEqual(make([]byte, 23, 23), result[13:]) {
+		t.Fatal("SSL request filler",
+			"expected 23 zero byte arrys",
+			"got", result[13:],
+		)
+	}
+}
+
+func TestHandshakeReadUnsupportedVersion(t *testing.T) {
+	packReader := newPackReader(bytes.NewBuffer([]byte{0x02, 0x00, 0x00, 0x00, 0x09, 0x00}))
+	pack, _ := packReader.readNextPack()
+
+	handshake := &pkgHandshake{}
+	if err := handshake.readServer(pack); err == nil {
+		t.Fatal("Incorrect handshake read", "expected", "unsupported version error", "got", nil)
+	}
+}
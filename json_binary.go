@@ -0,0 +1,346 @@
+package myreplication
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	_JSONB_SMALL_OBJECT = 0x00
+	_JSONB_LARGE_OBJECT = 0x01
+	_JSONB_SMALL_ARRAY  = 0x02
+	_JSONB_LARGE_ARRAY  = 0x03
+	_JSONB_LITERAL      = 0x04
+	_JSONB_INT16        = 0x05
+	_JSONB_UINT16       = 0x06
+	_JSONB_INT32        = 0x07
+	_JSONB_UINT32       = 0x08
+	_JSONB_INT64        = 0x09
+	_JSONB_UINT64       = 0x0a
+	_JSONB_DOUBLE       = 0x0b
+	_JSONB_STRING       = 0x0c
+	_JSONB_OPAQUE       = 0x0f
+
+	_JSONB_NULL_LITERAL  = 0x00
+	_JSONB_TRUE_LITERAL  = 0x01
+	_JSONB_FALSE_LITERAL = 0x02
+
+	//the nesting limit of the server
+	_JSONB_MAX_DEPTH = 100
+)
+
+var (
+	INVALID_JSON_BINARY_ERR = errors.New("invalid binary JSON value")
+)
+
+type jsonBinaryDecoder struct {
+	buff bytes.Buffer
+}
+
+// decodeJSONBinary converts a JSON column value of the binary format of MySQL
+// to JSON text, the empty value is null
+func decodeJSONBinary(data []byte) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	decoder := &jsonBinaryDecoder{}
+	if err := decoder.value(data[0], data[1:], 0); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(decoder.buff.Bytes()), nil
+}
+
+func (d *jsonBinaryDecoder) value(valueType byte, data []byte, depth int) error {
+	switch valueType {
+	case _JSONB_SMALL_OBJECT:
+		return d.container(data, false, true, depth)
+	case _JSONB_LARGE_OBJECT:
+		return d.container(data, true, true, depth)
+	case _JSONB_SMALL_ARRAY:
+		return d.container(data, false, false, depth)
+	case _JSONB_LARGE_ARRAY:
+		return d.container(data, true, false, depth)
+	case _JSONB_LITERAL, _JSONB_INT16, _JSONB_UINT16, _JSONB_INT32, _JSONB_UINT32:
+		return d.scalar(valueType, data)
+	case _JSONB_INT64:
+		if len(data) < 8 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		d.buff.WriteString(strconv.FormatInt(int64(binary.LittleEndian.Uint64(data)), 10))
+	case _JSONB_UINT64:
+		if len(data) < 8 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		d.buff.WriteString(strconv.FormatUint(binary.LittleEndian.Uint64(data), 10))
+	case _JSONB_DOUBLE:
+		if len(data) < 8 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return INVALID_JSON_BINARY_ERR
+		}
+		d.buff.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	case _JSONB_STRING:
+		value, err := readJSONBinaryData(data)
+		if err != nil {
+			return err
+		}
+		d.string(string(value))
+	case _JSONB_OPAQUE:
+		if len(data) < 1 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		value, err := readJSONBinaryData(data[1:])
+		if err != nil {
+			return err
+		}
+		return d.opaque(data[0], value)
+	default:
+		return INVALID_JSON_BINARY_ERR
+	}
+	return nil
+}
+
+// container writes an object or an array. Its header holds the element count
+// and the size, then the key entries of an object and the value entries, the
+// offsets are counted from the start of the container.
+func (d *jsonBinaryDecoder) container(data []byte, large, object bool, depth int) error {
+	if depth >= _JSONB_MAX_DEPTH {
+		return INVALID_JSON_BINARY_ERR
+	}
+
+	offsetSize := 2
+	if large {
+		offsetSize = 4
+	}
+
+	if len(data) < 2*offsetSize {
+		return INVALID_JSON_BINARY_ERR
+	}
+
+	count := readJSONBinaryOffset(data, offsetSize)
+	size := readJSONBinaryOffset(data[offsetSize:], offsetSize)
+	if size > uint64(len(data)) {
+		return INVALID_JSON_BINARY_ERR
+	}
+	data = data[:size]
+
+	keyEntrySize, valueEntrySize := uint64(0), uint64(1+offsetSize)
+	if object {
+		keyEntrySize = uint64(offsetSize + 2)
+	}
+
+	//the entries are checked against the size before they are read
+	if count > size {
+		return INVALID_JSON_BINARY_ERR
+	}
+	keyEntries := uint64(2 * offsetSize)
+	valueEntries := keyEntries + count*keyEntrySize
+	headerSize := valueEntries + count*valueEntrySize
+	if headerSize > size {
+		return INVALID_JSON_BINARY_ERR
+	}
+
+	if object {
+		d.buff.WriteByte('{')
+	} else {
+		d.buff.WriteByte('[')
+	}
+
+	for i := uint64(0); i < count; i++ {
+		if i > 0 {
+			d.buff.WriteByte(',')
+		}
+
+		if object {
+			entry := data[keyEntries+i*keyEntrySize:]
+			keyOffset := readJSONBinaryOffset(entry, offsetSize)
+			keyLength := uint64(binary.LittleEndian.Uint16(entry[offsetSize:]))
+			if keyOffset < headerSize || keyOffset+keyLength > size {
+				return INVALID_JSON_BINARY_ERR
+			}
+			d.string(string(data[keyOffset : keyOffset+keyLength]))
+			d.buff.WriteByte(':')
+		}
+
+		entry := data[valueEntries+i*valueEntrySize:]
+		valueType := entry[0]
+
+		if isJSONBinaryInlined(valueType, large) {
+			if err := d.scalar(valueType, entry[1:1+offsetSize]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		//a value lies after the header, nested containers shrink
+		valueOffset := readJSONBinaryOffset(entry[1:], offsetSize)
+		if valueOffset < headerSize || valueOffset >= size {
+			return INVALID_JSON_BINARY_ERR
+		}
+		if err := d.value(valueType, data[valueOffset:], depth+1); err != nil {
+			return err
+		}
+	}
+
+	if object {
+		d.buff.WriteByte('}')
+	} else {
+		d.buff.WriteByte(']')
+	}
+	return nil
+}
+
+// scalar writes the values that can be inlined in a value entry
+func (d *jsonBinaryDecoder) scalar(valueType byte, data []byte) error {
+	switch valueType {
+	case _JSONB_LITERAL:
+		if len(data) < 1 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		switch data[0] {
+		case _JSONB_NULL_LITERAL:
+			d.buff.WriteString("null")
+		case _JSONB_TRUE_LITERAL:
+			d.buff.WriteString("true")
+		case _JSONB_FALSE_LITERAL:
+			d.buff.WriteString("false")
+		default:
+			return INVALID_JSON_BINARY_ERR
+		}
+	case _JSONB_INT16, _JSONB_UINT16:
+		if len(data) < 2 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		value := binary.LittleEndian.Uint16(data)
+		if valueType == _JSONB_INT16 {
+			d.buff.WriteString(strconv.FormatInt(int64(int16(value)), 10))
+		} else {
+			d.buff.WriteString(strconv.FormatUint(uint64(value), 10))
+		}
+	case _JSONB_INT32, _JSONB_UINT32:
+		if len(data) < 4 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		value := binary.LittleEndian.Uint32(data)
+		if valueType == _JSONB_INT32 {
+			d.buff.WriteString(strconv.FormatInt(int64(int32(value)), 10))
+		} else {
+			d.buff.WriteString(strconv.FormatUint(uint64(value), 10))
+		}
+	default:
+		return INVALID_JSON_BINARY_ERR
+	}
+	return nil
+}
+
+// opaque writes the values of other MySQL types, DECIMAL as a number, the
+// temporal types as strings like CAST(... AS JSON) and the others in the
+// base64:type<type>:<data> form of the server
+func (d *jsonBinaryDecoder) opaque(fieldType byte, data []byte) error {
+	switch fieldType {
+	case MYSQL_TYPE_NEWDECIMAL:
+		if len(data) < 2 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		precision, scale := int(data[0]), int(data[1])
+		decimal, err := newPackWithBuff(data[2:]).readNewDecimal(precision, scale)
+		if err != nil {
+			return err
+		}
+		d.buff.WriteString(decimal.FloatString(scale))
+	case MYSQL_TYPE_DATE, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_TIME:
+		if len(data) < 8 {
+			return INVALID_JSON_BINARY_ERR
+		}
+		d.string(formatPackedTemporal(fieldType, int64(binary.LittleEndian.Uint64(data))))
+	default:
+		d.string(fmt.Sprintf("base64:type%d:%s", fieldType, base64.StdEncoding.EncodeToString(data)))
+	}
+	return nil
+}
+
+func (d *jsonBinaryDecoder) string(value string) {
+	writeJSONString(&d.buff, value)
+}
+
+func writeJSONString(buff *bytes.Buffer, value string) {
+	encoder := json.NewEncoder(buff)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	//the encoder ends the value with a newline
+	buff.Truncate(buff.Len() - 1)
+}
+
+// formatPackedTemporal formats the packed integer form of the server, the
+// integer part of date and time shifted by 24 bits above the microseconds
+func formatPackedTemporal(fieldType byte, packed int64) string {
+	sign := ""
+	if packed < 0 {
+		sign = "-"
+		packed = -packed
+	}
+
+	microsecond := packed % (1 << 24)
+	intPart := packed >> 24
+
+	if fieldType == MYSQL_TYPE_TIME {
+		return fmt.Sprintf("%s%02d:%02d:%02d.%06d",
+			sign, intPart>>12, intPart>>6&0x3f, intPart&0x3f, microsecond)
+	}
+
+	ymd := intPart >> 17
+	hms := intPart % (1 << 17)
+	yearMonth := ymd >> 5
+	date := fmt.Sprintf("%04d-%02d-%02d", yearMonth/13, yearMonth%13, ymd&0x1f)
+	if fieldType == MYSQL_TYPE_DATE {
+		return date
+	}
+
+	return fmt.Sprintf("%s %02d:%02d:%02d.%06d", date, hms>>12, hms>>6&0x3f, hms&0x3f, microsecond)
+}
+
+func isJSONBinaryInlined(valueType byte, large bool) bool {
+	switch valueType {
+	case _JSONB_LITERAL, _JSONB_INT16, _JSONB_UINT16:
+		return true
+	case _JSONB_INT32, _JSONB_UINT32:
+		return large
+	}
+	return false
+}
+
+func readJSONBinaryOffset(data []byte, size int) uint64 {
+	if size == 2 {
+		return uint64(binary.LittleEndian.Uint16(data))
+	}
+	return uint64(binary.LittleEndian.Uint32(data))
+}
+
+// readJSONBinaryData reads the data of a string or opaque value after its
+// length, 7 bits per byte with the high bit set on all but the last byte
+func readJSONBinaryData(data []byte) ([]byte, error) {
+	var length uint64
+	for i := 0; i < 5; i++ {
+		if i >= len(data) {
+			return nil, INVALID_JSON_BINARY_ERR
+		}
+
+		length |= uint64(data[i]&0x7f) << uint(7*i)
+		if data[i]&0x80 == 0 {
+			if length > uint64(len(data)-i-1) {
+				return nil, INVALID_JSON_BINARY_ERR
+			}
+			return data[i+1 : i+1+int(length)], nil
+		}
+	}
+	return nil, INVALID_JSON_BINARY_ERR
+}
@@ -0,0 +1,172 @@
+package myreplication
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func nestedJSONBinaryArray(depth int) []byte {
+	//the innermost array is empty, every other one holds the next
+	value := []byte{0x00, 0x00, 0x04, 0x00}
+	for i := 1; i < depth; i++ {
+		size := 7 + len(value)
+		value = append([]byte{0x01, 0x00, byte(size), byte(size >> 8), _JSONB_SMALL_ARRAY, 0x07, 0x00}, value...)
+	}
+	return append([]byte{_JSONB_SMALL_ARRAY}, value...)
+}
+
+func TestDecodeJSONBinary(t *testing.T) {
+	type (
+		testCase struct {
+			data     []byte
+			expected string
+		}
+	)
+
+	testCases := []*testCase{
+		&testCase{
+			data:     []byte{},
+			expected: `null`,
+		},
+		&testCase{
+			data:     []byte{0x00, 0x01, 0x00, 0x0c, 0x00, 0x0b, 0x00, 0x01, 0x00, 0x05, 0x01, 0x00, 0x61},
+			expected: `{"a":1}`,
+		},
+		&testCase{
+			data: []byte{
+				0x02, 0x05, 0x00, 0x13, 0x00,
+				0x04, 0x01, 0x00, 0x04, 0x02, 0x00, 0x04, 0x00, 0x00, 0x05, 0xff, 0xff, 0x06, 0xff, 0xff,
+			},
+			expected: `[true,false,null,-1,65535]`,
+		},
+		&testCase{
+			data: []byte{
+				0x00, 0x03, 0x00, 0x42, 0x00,
+				0x19, 0x00, 0x01, 0x00, 0x1a, 0x00, 0x01, 0x00, 0x1b, 0x00, 0x01, 0x00,
+				0x02, 0x1c, 0x00, 0x0b, 0x2c, 0x00, 0x0c, 0x34, 0x00,
+				'k', 'n', 's',
+				//[70000, "x"]
+				0x02, 0x00, 0x10, 0x00, 0x07, 0x0a, 0x00, 0x0c, 0x0e, 0x00, 0x70, 0x11, 0x01, 0x00, 0x01, 'x',
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x40,
+				0x0d, 's', 'a', 'y', ' ', '"', 'h', 'i', '"', ' ', '<', 0xc3, 0xa9, '>',
+			},
+			expected: `{"k":[70000,"x"],"n":3.5,"s":"say \"hi\" <é>"}`,
+		},
+		&testCase{
+			data: []byte{
+				0x01, 0x03, 0x00, 0x00, 0x00, 0x39, 0x00, 0x00, 0x00,
+				0x29, 0x00, 0x00, 0x00, 0x01, 0x00, 0x2a, 0x00, 0x00, 0x00, 0x01, 0x00, 0x2b, 0x00, 0x00, 0x00, 0x01, 0x00,
+				0x07, 0x60, 0x79, 0xfe, 0xff, 0x08, 0x00, 0x28, 0x6b, 0xee, 0x03, 0x2c, 0x00, 0x00, 0x00,
+				'a', 'b', 'c',
+				0x01, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00,
+			},
+			expected: `{"a":-100000,"b":4000000000,"c":[null]}`,
+		},
+		&testCase{
+			data:     []byte{0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff},
+			expected: `-1099511627776`,
+		},
+		&testCase{
+			data:     []byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			expected: `18446744073709551615`,
+		},
+		&testCase{
+			data:     append([]byte{0x0c, 0xc8, 0x01}, bytes.Repeat([]byte{'x'}, 200)...),
+			expected: `"` + string(bytes.Repeat([]byte{'x'}, 200)) + `"`,
+		},
+		&testCase{
+			data:     []byte{0x0f, MYSQL_TYPE_NEWDECIMAL, 0x04, 0x04, 0x02, 0x8c, 0x22},
+			expected: `12.34`,
+		},
+		&testCase{
+			data:     []byte{0x0f, MYSQL_TYPE_DATETIME, 0x08, 0x7b, 0x00, 0x00, 0xac, 0x6c, 0xbb, 0x95, 0x19},
+			expected: `"2015-03-29 22:50:44.000123"`,
+		},
+		&testCase{
+			data:     []byte{0x0f, MYSQL_TYPE_DATE, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0xba, 0x95, 0x19},
+			expected: `"2015-03-29"`,
+		},
+		&testCase{
+			data:     []byte{0x0f, MYSQL_TYPE_TIME, 0x08, 0xfc, 0xff, 0xff, 0x7c, 0xef, 0xff, 0xff, 0xff},
+			expected: `"-01:02:03.000004"`,
+		},
+		&testCase{
+			data:     []byte{0x0f, MYSQL_TYPE_BLOB, 0x02, 'a', 'b'},
+			expected: `"base64:type252:YWI="`,
+		},
+		&testCase{
+			data:     nestedJSONBinaryArray(3),
+			expected: `[[[]]]`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		value, err := decodeJSONBinary(testCase.data)
+		if err != nil {
+			t.Fatal("Decode JSON fail at test", i, err)
+		}
+
+		if string(value) != testCase.expected {
+			t.Fatal("Incorrect JSON at test", i, "expected", testCase.expected, "got", string(value))
+		}
+
+		if !json.Valid(value) {
+			t.Fatal("Incorrect JSON at test", i, "expected", "valid JSON", "got", string(value))
+		}
+	}
+
+	if _, err := decodeJSONBinary(nestedJSONBinaryArray(_JSONB_MAX_DEPTH)); err != nil {
+		t.Fatal("Decode JSON fail at max depth", err)
+	}
+}
+
+func TestDecodeJSONBinaryInvalid(t *testing.T) {
+	testCases := [][]byte{
+		//unknown type
+		[]byte{0x0d},
+		//unknown literal
+		[]byte{0x04, 0x03},
+		//short int64
+		[]byte{0x09, 0x01, 0x02},
+		//string longer than the value
+		[]byte{0x0c, 0x05, 'a'},
+		//object without its key
+		[]byte{0x00, 0x01, 0x00, 0x0c, 0x00, 0x0b, 0x00, 0x01, 0x00, 0x05, 0x01, 0x00},
+		//array holding itself
+		[]byte{0x02, 0x01, 0x00, 0x07, 0x00, 0x02, 0x00, 0x00},
+		//too many entries for the size
+		[]byte{0x02, 0xff, 0x00, 0x07, 0x00, 0x04, 0x00, 0x00},
+		//short decimal
+		[]byte{0x0f, MYSQL_TYPE_NEWDECIMAL, 0x03, 0x04, 0x02, 0x8c},
+		nestedJSONBinaryArray(_JSONB_MAX_DEPTH + 1),
+	}
+
+	for i, data := range testCases {
+		if value, err := decodeJSONBinary(data); err == nil {
+			t.Fatal("Incorrect JSON accepted at test", i, "got", string(value))
+		}
+	}
+}
+
+func FuzzDecodeJSONBinary(f *testing.F) {
+	//seeds from TestDecodeJSONBinary
+	f.Add([]byte{0x00, 0x01, 0x00, 0x0c, 0x00, 0x0b, 0x00, 0x01, 0x00, 0x05, 0x01, 0x00, 0x61})
+	f.Add([]byte{
+		0x01, 0x03, 0x00, 0x00, 0x00, 0x39, 0x00, 0x00, 0x00,
+		0x29, 0x00, 0x00, 0x00, 0x01, 0x00, 0x2a, 0x00, 0x00, 0x00, 0x01, 0x00, 0x2b, 0x00, 0x00, 0x00, 0x01, 0x00,
+		0x07, 0x60, 0x79, 0xfe, 0xff, 0x08, 0x00, 0x28, 0x6b, 0xee, 0x03, 0x2c, 0x00, 0x00, 0x00,
+		'a', 'b', 'c',
+		0x01, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00,
+	})
+	f.Add([]byte{0x0f, MYSQL_TYPE_NEWDECIMAL, 0x04, 0x04, 0x02, 0x8c, 0x22})
+	f.Add([]byte{0x0f, MYSQL_TYPE_DATETIME, 0x08, 0x7b, 0x00, 0x00, 0xac, 0x6c, 0xbb, 0x95, 0x19})
+	f.Add(nestedJSONBinaryArray(3))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value, err := decodeJSONBinary(data)
+		if err == nil && !json.Valid(value) {
+			t.Fatal("Incorrect JSON", "expected", "valid JSON", "got", string(value))
+		}
+	})
+}
@@ -0,0 +1,374 @@
+package myreplication
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	JSONDiffOperation byte
+
+	// JSONDiff is a change of a partially updated JSON column, Path is in the
+	// MySQL syntax like $.a[1] and Value is not set for a removal
+	JSONDiff struct {
+		Operation JSONDiffOperation
+		Path      string
+		Value     json.RawMessage
+	}
+
+	jsonPathLeg struct {
+		member  string
+		index   int
+		isIndex bool
+	}
+)
+
+const (
+	JSON_DIFF_REPLACE JSONDiffOperation = 0x00
+	JSON_DIFF_INSERT  JSONDiffOperation = 0x01
+	JSON_DIFF_REMOVE  JSONDiffOperation = 0x02
+)
+
+var (
+	INVALID_JSON_DIFF_ERR = errors.New("invalid JSON diff")
+	INVALID_JSON_PATH_ERR = errors.New("invalid JSON path")
+)
+
+func (o JSONDiffOperation) String() string {
+	switch o {
+	case JSON_DIFF_REPLACE:
+		return "REPLACE"
+	case JSON_DIFF_INSERT:
+		return "INSERT"
+	case JSON_DIFF_REMOVE:
+		return "REMOVE"
+	}
+	return fmt.Sprintf("JSONDiffOperation(%d)", byte(o))
+}
+
+// readJSONDiffs reads a partial JSON value, every diff holds the operation,
+// the path and but for a removal the value in the binary format
+func readJSONDiffs(data []byte) ([]*JSONDiff, error) {
+	pack := newPackWithBuff(data)
+	diffs := []*JSONDiff{}
+
+	for pack.Len() > 0 {
+		operation, _ := pack.ReadByte()
+		if JSONDiffOperation(operation) > JSON_DIFF_REMOVE {
+			return nil, INVALID_JSON_DIFF_ERR
+		}
+
+		path, err := pack.readStringLength()
+		if err != nil {
+			return nil, err
+		}
+
+		diff := &JSONDiff{Operation: JSONDiffOperation(operation), Path: string(path)}
+		if diff.Operation != JSON_DIFF_REMOVE {
+			value, err := pack.readStringLength()
+			if err != nil {
+				return nil, err
+			}
+			if diff.Value, err = decodeJSONBinary(value); err != nil {
+				return nil, err
+			}
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// ApplyJSONDiffs applies the diffs of a partial update to the document of the
+// before image and returns the document of the after image
+func ApplyJSONDiffs(document json.RawMessage, diffs []*JSONDiff) (json.RawMessage, error) {
+	root, err := unmarshalJSON(document)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, diff := range diffs {
+		legs, err := parseJSONPath(diff.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if diff.Operation != JSON_DIFF_REMOVE {
+			if value, err = unmarshalJSON(diff.Value); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(legs) == 0 {
+			//only the whole document can be replaced at $
+			if diff.Operation != JSON_DIFF_REPLACE {
+				return nil, INVALID_JSON_DIFF_ERR
+			}
+			root = value
+			continue
+		}
+
+		if root, err = applyJSONDiff(root, legs, diff.Operation, value); err != nil {
+			return nil, err
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	writeJSON(buff, root)
+	return json.RawMessage(buff.Bytes()), nil
+}
+
+func applyJSONDiff(node interface{}, legs []jsonPathLeg, operation JSONDiffOperation, value interface{}) (interface{}, error) {
+	leg := legs[0]
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		if leg.isIndex {
+			return nil, INVALID_JSON_DIFF_ERR
+		}
+
+		child, exists := container[leg.member]
+		if len(legs) > 1 {
+			if !exists {
+				return nil, INVALID_JSON_DIFF_ERR
+			}
+			child, err := applyJSONDiff(child, legs[1:], operation, value)
+			if err != nil {
+				return nil, err
+			}
+			container[leg.member] = child
+			return container, nil
+		}
+
+		switch operation {
+		case JSON_DIFF_REPLACE, JSON_DIFF_REMOVE:
+			if !exists {
+				return nil, INVALID_JSON_DIFF_ERR
+			}
+		}
+
+		if operation == JSON_DIFF_REMOVE {
+			delete(container, leg.member)
+		} else {
+			container[leg.member] = value
+		}
+		return container, nil
+	case []interface{}:
+		if !leg.isIndex {
+			return nil, INVALID_JSON_DIFF_ERR
+		}
+
+		if len(legs) > 1 {
+			if leg.index >= len(container) {
+				return nil, INVALID_JSON_DIFF_ERR
+			}
+			child, err := applyJSONDiff(container[leg.index], legs[1:], operation, value)
+			if err != nil {
+				return nil, err
+			}
+			container[leg.index] = child
+			return container, nil
+		}
+
+		switch operation {
+		case JSON_DIFF_REPLACE:
+			if leg.index >= len(container) {
+				return nil, INVALID_JSON_DIFF_ERR
+			}
+			container[leg.index] = value
+		case JSON_DIFF_INSERT:
+			//an index past the end appends
+			index := leg.index
+			if index > len(container) {
+				index = len(container)
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+		case JSON_DIFF_REMOVE:
+			if leg.index >= len(container) {
+				return nil, INVALID_JSON_DIFF_ERR
+			}
+			container = append(container[:leg.index], container[leg.index+1:]...)
+		}
+		return container, nil
+	}
+
+	return nil, INVALID_JSON_DIFF_ERR
+}
+
+// parseJSONPath parses the paths the server writes in the diffs, $ followed by
+// .member, ."quoted member" and [index] legs
+func parseJSONPath(path string) ([]jsonPathLeg, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, INVALID_JSON_PATH_ERR
+	}
+	path = path[1:]
+
+	legs := []jsonPathLeg{}
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			if strings.HasPrefix(path, `"`) {
+				end := 1
+				for end < len(path) && path[end] != '"' {
+					if path[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end >= len(path) {
+					return nil, INVALID_JSON_PATH_ERR
+				}
+
+				var member string
+				if err := json.Unmarshal([]byte(path[:end+1]), &member); err != nil {
+					return nil, INVALID_JSON_PATH_ERR
+				}
+				legs = append(legs, jsonPathLeg{member: member})
+				path = path[end+1:]
+				continue
+			}
+
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			member := path[:end]
+			//wildcards do not name a single value
+			if member == "" || strings.Contains(member, "*") {
+				return nil, INVALID_JSON_PATH_ERR
+			}
+			legs = append(legs, jsonPathLeg{member: member})
+			path = path[end:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, INVALID_JSON_PATH_ERR
+			}
+			index, err := strconv.ParseUint(strings.TrimSpace(path[1:end]), 10, 31)
+			if err != nil {
+				return nil, INVALID_JSON_PATH_ERR
+			}
+			legs = append(legs, jsonPathLeg{index: int(index), isIndex: true})
+			path = path[end+1:]
+		default:
+			return nil, INVALID_JSON_PATH_ERR
+		}
+	}
+
+	return legs, nil
+}
+
+func unmarshalJSON(document json.RawMessage) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	//numbers keep their text, like DECIMAL values
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// writeJSON writes the keys of objects in the order of the server, shorter
+// keys first, like decodeJSONBinary
+func writeJSON(buff *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+
+		buff.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buff.WriteByte(',')
+			}
+			writeJSONString(buff, key)
+			buff.WriteByte(':')
+			writeJSON(buff, v[key])
+		}
+		buff.WriteByte('}')
+	case []interface{}:
+		buff.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buff.WriteByte(',')
+			}
+			writeJSON(buff, item)
+		}
+		buff.WriteByte(']')
+	case string:
+		writeJSONString(buff, v)
+	case json.Number:
+		buff.WriteString(string(v))
+	case bool:
+		buff.WriteString(strconv.FormatBool(v))
+	default:
+		buff.WriteString("null")
+	}
+}
+
+// GetFullNewRows returns the rows of the after image with the partial JSON
+// values applied to the documents of the before image
+func (event *UpdateEvent) GetFullNewRows() ([][]*RowsEventValue, error) {
+	rows := make([][]*RowsEventValue, len(event.newValues))
+
+	for i, row := range event.newValues {
+		rows[i] = make([]*RowsEventValue, len(row))
+
+		for j, value := range row {
+			rows[i][j] = value
+			diffs, ok := value.value.([]*JSONDiff)
+			if !ok {
+				continue
+			}
+
+			var before *RowsEventValue
+			if i < len(event.values) {
+				for _, beforeValue := range event.values[i] {
+					if beforeValue.columnId == value.columnId {
+						before = beforeValue
+					}
+				}
+			}
+
+			document, ok := json.RawMessage(nil), false
+			if before != nil {
+				document, ok = before.value.(json.RawMessage)
+			}
+			if !ok {
+				return nil, fmt.Errorf("no JSON document of column %d in the before image of row %d", value.columnId, i)
+			}
+
+			document, err := ApplyJSONDiffs(document, diffs)
+			if err != nil {
+				return nil, err
+			}
+
+			rows[i][j] = &RowsEventValue{
+				columnId: value.columnId,
+				value:    document,
+				_type:    value._type,
+			}
+		}
+	}
+
+	return rows, nil
+}
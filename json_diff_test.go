@@ -0,0 +1,141 @@
+package myreplication
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestReadJSONDiffs(t *testing.T) {
+	data := []byte{
+		0x00, 0x03, '$', '.', 'a', 0x03, 0x05, 0x02, 0x00,
+		0x01, 0x06, '$', '.', 'b', '[', '0', ']', 0x03, 0x0c, 0x01, 'x',
+		0x02, 0x03, '$', '.', 'c',
+	}
+
+	diffs, err := readJSONDiffs(data)
+	if err != nil {
+		t.Fatal("Read JSON diffs fail", err)
+	}
+
+	expected := []*JSONDiff{
+		&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.a", Value: json.RawMessage(`2`)},
+		&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.b[0]", Value: json.RawMessage(`"x"`)},
+		&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$.c"},
+	}
+
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Fatal("Incorrect JSON diffs", "expected", expected, "got", diffs)
+	}
+
+	//a cut between two diffs leaves whole diffs
+	for i := 1; i < len(data); i++ {
+		if _, err := readJSONDiffs(data[:i]); err == nil && i != 9 && i != 21 {
+			t.Fatal("Incorrect JSON diffs accepted at length", i)
+		}
+	}
+
+	if _, err := readJSONDiffs([]byte{0x03, 0x01, '$'}); err == nil {
+		t.Fatal("Incorrect JSON diff operation accepted")
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	legs, err := parseJSONPath(`$.a."b \"c\""[12].d`)
+	if err != nil {
+		t.Fatal("Parse JSON path fail", err)
+	}
+
+	expected := []jsonPathLeg{
+		jsonPathLeg{member: "a"},
+		jsonPathLeg{member: `b "c"`},
+		jsonPathLeg{index: 12, isIndex: true},
+		jsonPathLeg{member: "d"},
+	}
+
+	if !reflect.DeepEqual(legs, expected) {
+		t.Fatal("Incorrect JSON path", "expected", expected, "got", legs)
+	}
+
+	for _, path := range []string{"", "a", "$.", "$.*", "$[*]", "$[x]", "$[1", `$."a`, "$**.a"} {
+		if legs, err := parseJSONPath(path); err == nil {
+			t.Fatal("Incorrect JSON path accepted", path, "got", legs)
+		}
+	}
+}
+
+func TestApplyJSONDiffs(t *testing.T) {
+	type (
+		testCase struct {
+			diffs    []*JSONDiff
+			expected string
+		}
+	)
+
+	document := json.RawMessage(`{"a":[1,2,3],"b":{"c":"d"},"price":12.50}`)
+
+	testCases := []*testCase{
+		&testCase{
+			diffs:    []*JSONDiff{},
+			expected: `{"a":[1,2,3],"b":{"c":"d"},"price":12.50}`,
+		},
+		&testCase{
+			diffs: []*JSONDiff{
+				&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.a[1]", Value: json.RawMessage(`20`)},
+				&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.b.c", Value: json.RawMessage(`{"e":null}`)},
+			},
+			expected: `{"a":[1,20,3],"b":{"c":{"e":null}},"price":12.50}`,
+		},
+		&testCase{
+			diffs: []*JSONDiff{
+				&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.a[0]", Value: json.RawMessage(`0`)},
+				&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.a[10]", Value: json.RawMessage(`4`)},
+				&JSONDiff{Operation: JSON_DIFF_INSERT, Path: `$."a b"`, Value: json.RawMessage(`true`)},
+			},
+			expected: `{"a":[0,1,2,3,4],"b":{"c":"d"},"a b":true,"price":12.50}`,
+		},
+		&testCase{
+			diffs: []*JSONDiff{
+				&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$.a[0]"},
+				&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$.b.c"},
+				&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$.price"},
+			},
+			expected: `{"a":[2,3],"b":{}}`,
+		},
+		&testCase{
+			diffs: []*JSONDiff{
+				&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$", Value: json.RawMessage(`"<x>"`)},
+			},
+			expected: `"<x>"`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		value, err := ApplyJSONDiffs(document, testCase.diffs)
+		if err != nil {
+			t.Fatal("Apply JSON diffs fail at test", i, err)
+		}
+
+		if string(value) != testCase.expected {
+			t.Fatal("Incorrect JSON at test", i, "expected", testCase.expected, "got", string(value))
+		}
+	}
+
+	invalidDiffs := []*JSONDiff{
+		&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.x", Value: json.RawMessage(`1`)},
+		&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.a[3]", Value: json.RawMessage(`1`)},
+		&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$.a[3]"},
+		&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$.x"},
+		&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.x.y", Value: json.RawMessage(`1`)},
+		&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.a.x", Value: json.RawMessage(`1`)},
+		&JSONDiff{Operation: JSON_DIFF_INSERT, Path: "$.b[0]", Value: json.RawMessage(`1`)},
+		&JSONDiff{Operation: JSON_DIFF_REMOVE, Path: "$"},
+		&JSONDiff{Operation: JSON_DIFF_REPLACE, Path: "$.a", Value: json.RawMessage(`{`)},
+	}
+
+	for i, diff := range invalidDiffs {
+		if value, err := ApplyJSONDiffs(document, []*JSONDiff{diff}); err == nil {
+			t.Fatal("Incorrect JSON diff accepted at test", i, "got", string(value))
+		}
+	}
+}
@@ -2,8 +2,7 @@ package myreplication
 
 import (
 	"bytes"
-	"encoding/binary"
-	"fmt"
+	"errors"
 	"io"
 	"math/big"
 	"strconv"
@@ -29,6 +28,13 @@ type (
 
 var (
 	compressedBytes = []int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+
+	SHORT_PACKET_ERR = errors.New("packet is shorter than its content")
+)
+
+const (
+	_DECIMAL_MAX_PRECISION = 65
+	_DECIMAL_MAX_SCALE     = 30
 )
 
 func newPackReader(conn io.Reader) *packReader {
@@ -68,25 +74,25 @@ func (r *packReader) readNextPackWithAdditionalLength(addLength int) (*pack, err
 	buff := make([]byte, 4)
 
 	if _, err := io.ReadFull(r.conn, buff); err != nil {
-		println("got err")
 		return nil, err
 	}
 
 	var length uint32
 	if err := readThreeBytesUint32(buff[0:3], &length); err != nil {
-		println("got err in three bytes")
 		return nil, err
 	}
 
+	if int(length) < addLength {
+		return nil, SHORT_PACKET_ERR
+	}
+
 	pack := &pack{
 		sequence: buff[3],
 		length:   length,
 		buff:     make([]byte, length),
 	}
 
-	if n, err := io.ReadFull(r.conn, pack.buff); err != nil {
-		fmt.Println("expect: ", length, "got", n)
-		println("read not full")
+	if _, err := io.ReadFull(r.conn, pack.buff); err != nil {
 		return nil, err
 	}
 
@@ -135,10 +141,22 @@ func (r *pack) readUint64(dest *uint64) error {
 	return readUint64(r.Buffer.Next(8), dest)
 }
 
+// next returns the following n bytes, unlike Next it fails when fewer are left
+func (r *pack) next(n int) ([]byte, error) {
+	if n < 0 || r.Len() < n {
+		return nil, SHORT_PACKET_ERR
+	}
+	return r.Buffer.Next(n), nil
+}
+
 func (r *pack) readUint64BySize(size int) (uint64, error) {
+	buff, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
 
 	var ret uint64
-	if err := readFixByteUint64(r.Buffer.Next(size), &ret); err != nil {
+	if err := readFixByteUint64(buff, &ret); err != nil {
 		return 0, err
 	}
 
@@ -148,34 +166,38 @@ func (r *pack) readUint64BySize(size int) (uint64, error) {
 /*
  * YYYY<< 9 + MM << 5 + DD
  */
-func (r *pack) readDate() time.Time {
+func (r *pack) readDate(loc *time.Location) (time.Time, error) {
 	var value uint32
-	r.readThreeByteUint32(&value)
+	if err := r.readThreeByteUint32(&value); err != nil {
+		return time.Time{}, err
+	}
 	if value == 0 {
-		return time.Time{}.In(time.Local)
+		return time.Time{}.In(loc), nil
 	}
 
 	var year int
 	if year = int((value & (((1 << 15) - 1) << 9)) >> 9); year == 0 {
-		return time.Time{}.In(time.Local)
+		return time.Time{}.In(loc), nil
 	}
 
 	month := int((value & (((1 << 4) - 1) << 5)) >> 5)
 	day := int(value & ((1 << 5) - 1))
 
 	date := time.Date(
-		year, time.Month(month), day, 0, 0, 0, 0, time.Local,
+		year, time.Month(month), day, 0, 0, 0, 0, loc,
 	)
 
-	return date
+	return date, nil
 }
 
-func (r *pack) readDateTime() time.Time {
+func (r *pack) readDateTime(loc *time.Location) (time.Time, error) {
 
 	var value uint64
-	r.readUint64(&value)
+	if err := r.readUint64(&value); err != nil {
+		return time.Time{}, err
+	}
 	if value == 0 {
-		return time.Time{}.In(time.Local)
+		return time.Time{}.In(loc), nil
 	}
 
 	date := value / 1000000
@@ -186,17 +208,47 @@ func (r *pack) readDateTime() time.Time {
 	day := int(date % 100)
 
 	if year == 0 || month == 0 || day == 0 {
-		return time.Time{}.In(time.Local)
+		return time.Time{}.In(loc), nil
 	}
 
-	return time.Date(int(year), time.Month(month), int(day), int(timev/10000), int((timev%10000)/100), int(timev%100), 0, time.Local)
+	return time.Date(int(year), time.Month(month), int(day), int(timev/10000), int((timev%10000)/100), int(timev%100), 0, loc), nil
 }
 
-func (r *pack) readTimestamp() time.Time {
+func (r *pack) readTimestamp(loc *time.Location) (time.Time, error) {
 	var value uint32
-	r.readUint32(&value)
+	if err := r.readUint32(&value); err != nil {
+		return time.Time{}, err
+	}
+	if value == 0 {
+		return time.Time{}.In(loc), nil
+	}
+
+	return time.Unix(int64(value), 0).In(loc), nil
+}
+
+// readTimestamp2 reads the big endian seconds since the epoch followed by the
+// fractional part, the zero timestamp is the zero time
+func (r *pack) readTimestamp2(fsp uint8, loc *time.Location) (time.Time, error) {
+	buff, err := r.next(4)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var seconds int64
+	for _, b := range buff {
+		seconds = seconds<<8 | int64(b)
+	}
+
+	microsecond, err := r.readFsp(fsp)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if seconds == 0 && microsecond == 0 {
+		return time.Time{}.In(loc), nil
+	}
 
-	return time.Unix(int64(value), 0)
+	return time.Unix(seconds, int64(microsecond)*int64(time.Microsecond)).In(loc), nil
 }
 
 /*
@@ -210,57 +262,159 @@ func (r *pack) readTimestamp() time.Time {
    6 bits minute         (0-59)
    6 bits second         (0-59)
    ---------------------------
-   40 bits = 5 bytes
+   40 bits = 5 bytes, big endian, followed by the fractional part
 */
-func (r *pack) readDateTime2(fsp uint8) time.Time {
-	data := binary.BigEndian.Uint64(r.Buffer.Next(40))
-	year_month := readBinarySlice(data, 1, 17, 40)
-	t := time.Date(
-		int(year_month/13), time.Month(int(year_month%13)), int(readBinarySlice(data, 18, 5, 40)),
+func (r *pack) readDateTime2(fsp uint8, loc *time.Location) (time.Time, error) {
+	buff, err := r.next(5)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var data uint64
+	for _, b := range buff {
+		data = data<<8 | uint64(b)
+	}
+
+	microsecond, err := r.readFsp(fsp)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	yearMonth := readBinarySlice(data, 1, 17, 40)
+	day := readBinarySlice(data, 18, 5, 40)
+	if yearMonth == 0 && day == 0 {
+		return time.Time{}.In(loc), nil
+	}
+
+	return time.Date(
+		int(yearMonth/13), time.Month(int(yearMonth%13)), int(day),
 		int(readBinarySlice(data, 23, 5, 40)),
 		int(readBinarySlice(data, 28, 6, 40)),
-		int(readBinarySlice(data, 34, 6, 40)), 0, time.Local)
-	return r.addFspTime(t, fsp)
+		int(readBinarySlice(data, 34, 6, 40)),
+		microsecond*int(time.Microsecond), loc), nil
 }
 
-func (r *pack) addFspTime(t time.Time, fsp uint8) time.Time {
-	read := 0
-	switch fsp {
-	case 1, 2:
-		read = 1
-	case 3, 4:
-		read = 2
-	case 5, 6:
-		read = 3
+// readFsp reads the big endian fractional seconds of the temporal types,
+// one byte per two digits of fsp, and returns microseconds
+func (r *pack) readFsp(fsp uint8) (int, error) {
+	if fsp > 6 {
+		return 0, errors.New("incorrect fractional seconds precision")
 	}
 
-	if read == 0 {
-		return t
+	length := int(fsp+1) / 2
+	if length == 0 {
+		return 0, nil
+	}
+
+	buff, err := r.next(length)
+	if err != nil {
+		return 0, err
+	}
+
+	value := 0
+	for _, b := range buff {
+		value = value<<8 | int(b)
 	}
 
-	microsec := binary.BigEndian.Uint32(r.Buffer.Next(8 * read))
-	if fsp%2 == 1 {
-		t.Add(time.Microsecond * time.Duration(int(microsec/10)))
-	} else {
-		t.Add(time.Microsecond * time.Duration(microsec))
+	//the value has 2, 4 or 6 digits
+	for i := length; i < 3; i++ {
+		value *= 100
 	}
 
-	return t
+	return value, nil
 }
 
+// readBinarySlice returns size bits starting from the start bit, bits are
+// counted from 0 at the most significant one of the datalen bits
 func readBinarySlice(data uint64, start, size, datalen uint32) uint64 {
-	data = data >> (datalen - start + size)
+	data = data >> (datalen - start - size)
 	return data & ((1 << size) - 1)
 }
 
-func (r *pack) readTime() time.Duration {
-	length, _ := r.ReadByte()
+/*
+   TIME2
+
+   1 bit  sign           (1= non-negative, 0= negative)
+   1 bit  unused         (reserved for wider values)
+   10 bits hour          (0-838)
+   6 bits minute         (0-59)
+   6 bits second         (0-59)
+   ---------------------------
+   24 bits = 3 bytes, big endian, followed by the fractional part. A negative
+   value is stored as the two's complement of the whole packed value.
+*/
+func (r *pack) readTime2(fsp uint8) (time.Duration, error) {
+	if fsp > 6 {
+		return 0, errors.New("incorrect fractional seconds precision")
+	}
+
+	length := 3 + int(fsp+1)/2
+	buff, err := r.next(length)
+	if err != nil {
+		return 0, err
+	}
+
+	var data int64
+	for _, b := range buff {
+		data = data<<8 | int64(b)
+	}
+
+	//the integer part is offset by 0x800000, the whole value by 0x800000 << fraction bits
+	intPart := int64(buff[0])<<16 | int64(buff[1])<<8 | int64(buff[2]) - 0x800000
+	var packed int64
+	switch length {
+	case 3:
+		packed = intPart << 24
+	case 4:
+		frac := data & 0xff
+		if intPart < 0 && frac != 0 {
+			intPart++
+			frac -= 0x100
+		}
+		packed = intPart<<24 + frac*10000
+	case 5:
+		frac := data & 0xffff
+		if intPart < 0 && frac != 0 {
+			intPart++
+			frac -= 0x10000
+		}
+		packed = intPart<<24 + frac*100
+	case 6:
+		packed = data - 0x800000000000
+	}
+
+	negative := packed < 0
+	if negative {
+		packed = -packed
+	}
+
+	hms := packed >> 24
+	d := time.Duration(hms>>12&0x3ff)*time.Hour +
+		time.Duration(hms>>6&0x3f)*time.Minute +
+		time.Duration(hms&0x3f)*time.Second +
+		time.Duration(packed&0xffffff)*time.Microsecond
+
+	if negative {
+		return -d, nil
+	}
+	return d, nil
+}
+
+func (r *pack) readTime() (time.Duration, error) {
+	length, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
 	var days uint32
 	var hour, minute, second byte
 	var microSecond uint32
 
 	if length == 0 {
-		return time.Duration(0)
+		return time.Duration(0), nil
+	}
+
+	if length != 8 && length != 12 || r.Len() < int(length) {
+		return 0, SHORT_PACKET_ERR
 	}
 
 	isNegative, _ := r.ReadByte()
@@ -283,19 +437,26 @@ func (r *pack) readTime() time.Duration {
 	)
 
 	if isNegative == 1 {
-		return -d
+		return -d, nil
 	}
 
-	return d
+	return d, nil
 }
 
 //got from https://github.com/whitesock/open-replicator toDecimal method
 // and https://github.com/jeremycole/mysql_binlog/blob/master/lib/mysql_binlog/binlog_field_parser.rb#L233
 //mysql.com have incorrect manual
-func (r *pack) readNewDecimal(precission, scale int) *big.Rat {
+func (r *pack) readNewDecimal(precission, scale int) (*big.Rat, error) {
+	if precission < 1 || precission > _DECIMAL_MAX_PRECISION || scale < 0 || scale > _DECIMAL_MAX_SCALE || scale > precission {
+		return nil, errors.New("incorrect decimal precision")
+	}
+
 	size := getDecimalBinarySize(precission, scale)
 
-	buff := r.Next(size)
+	buff, err := r.next(size)
+	if err != nil {
+		return nil, err
+	}
 	positive := (buff[0] & 0x80) == 0x80
 	buff[0] ^= 0x80
 
@@ -343,9 +504,12 @@ func (r *pack) readNewDecimal(precission, scale int) *big.Rat {
 		value += decimalPack.readDecimalStringBySize(size)
 	}
 
-	rat, _ := new(big.Rat).SetString(value)
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, errors.New("incorrect decimal value")
+	}
 
-	return rat
+	return rat, nil
 }
 
 func (r *pack) readDecimalStringBySize(size int) string {
@@ -396,6 +560,10 @@ func (r *pack) readStringLength() ([]byte, error) {
 		return []byte{}, nil
 	}
 
+	if length > uint64(r.Len()) {
+		return []byte{}, SHORT_PACKET_ERR
+	}
+
 	ret := r.Next(int(length))
 	return ret, nil
 }
@@ -412,28 +580,44 @@ func (r *pack) readIntLengthOrNil(value *uint64, null *bool) error {
 		*null = true
 	case 0xFC:
 		var val uint16
-		r.readUint16(&val)
+		err = r.readUint16(&val)
 		*value = uint64(val)
 	case 0xFD:
 		var val uint32
-		r.readThreeByteUint32(&val)
+		err = r.readThreeByteUint32(&val)
 		*value = uint64(val)
 	case 0xFE:
-		r.readUint64(value)
+		err = r.readUint64(value)
 	default:
 		*value = uint64(lb)
 	}
-	return nil
+	return err
+}
+
+// readIntLength reads a length encoded integer, NULL reads as 0
+func (r *pack) readIntLength() (uint64, error) {
+	var (
+		value uint64
+		null  bool
+	)
+	err := r.readIntLengthOrNil(&value, &null)
+	return value, err
 }
 
 func (r *pack) readStringBySize(size int) (string, error) {
 	var i uint64
-	var err error
-	if err = readFixByteUint64(r.Buffer.Next(size), &i); err != nil {
+	buff, err := r.next(size)
+	if err != nil {
+		return "", err
+	}
+	if err = readFixByteUint64(buff, &i); err != nil {
 		return "", err
-	} else {
-		return string(r.Buffer.Next(int(i))), nil
 	}
+
+	if i > uint64(r.Len()) {
+		return "", SHORT_PACKET_ERR
+	}
+	return string(r.Buffer.Next(int(i))), nil
 }
 
 func (r *pack) writeUInt16(data uint16) error {
@@ -503,7 +687,14 @@ func (r *pack) packBytes() []byte {
 }
 
 func (r *pack) isError() error {
+	if len(r.buff) == 0 {
+		return SHORT_PACKET_ERR
+	}
+
 	if r.buff[0] == _MYSQL_ERR {
+		if len(r.buff) < 3 {
+			return SHORT_PACKET_ERR
+		}
 		errPack := &errPacket{}
 		readUint16(r.buff[1:3], &errPack.code)
 		errPack.description = r.buff[3:]
@@ -514,7 +705,7 @@ func (r *pack) isError() error {
 }
 
 func (r *pack) isEOF() bool {
-	return r.buff[0] == _MYSQL_EOF
+	return len(r.buff) > 0 && r.buff[0] == _MYSQL_EOF
 }
 
 func getDecimalBinarySize(precission, scale int) int {
@@ -755,7 +755,10 @@ func TestReadDate(t *testing.T) {
 		reader := newPackReader(bytes.NewBuffer(testCase.buff))
 		pack, _ := reader.readNextPack()
 
-		result := pack.readDate()
+		result, err := pack.readDate(time.Local)
+		if err != nil {
+			t.Fatal("Read date fail at test", i, err)
+		}
 
 		if !testCase.expectedTime.Equal(result) {
 			t.Fatal(
@@ -817,7 +820,10 @@ func TestReadDateTime(t *testing.T) {
 		reader := newPackReader(bytes.NewBuffer(testCase.buff))
 		pack, _ := reader.readNextPack()
 
-		result := pack.readDateTime()
+		result, err := pack.readDateTime(time.Local)
+		if err != nil {
+			t.Fatal("Read date time fail at test", i, err)
+		}
 
 		if !testCase.expectedTime.Equal(result) {
 			t.Fatal(
@@ -829,20 +835,53 @@ func TestReadDateTime(t *testing.T) {
 	}
 }
 
-/*
 func TestReadDateTime2(t *testing.T) {
 	type dateTime2TestCase struct {
 		buff         []byte
+		fsp          uint8
 		expectedTime time.Time
 	}
 
 	testCases := []*dateTime2TestCase{
 		&dateTime2TestCase{
 			buff: []byte{
-				0x10, 0x00, 0x00, 0x01,
-				0x0b, 0xda, 0x07, 0x0a, 0x11, 0x13, 0x1b, 0x1e, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00,
+				0x05, 0x00, 0x00, 0x01,
+				0x99, 0x87, 0x23, 0x36, 0xde,
+			},
+			fsp:          0,
+			expectedTime: time.Date(2010, 10, 17, 19, 27, 30, 0, time.Local),
+		},
+		&dateTime2TestCase{
+			buff: []byte{
+				0x06, 0x00, 0x00, 0x01,
+				0x99, 0x95, 0xbb, 0x6c, 0xac, 0x05,
+			},
+			fsp:          1,
+			expectedTime: time.Date(2015, 3, 29, 22, 50, 44, 50000000, time.Local),
+		},
+		&dateTime2TestCase{
+			buff: []byte{
+				0x07, 0x00, 0x00, 0x01,
+				0x99, 0x95, 0xbb, 0x6c, 0xac, 0x04, 0xd2,
 			},
-			expectedTime: time.Date(2010, 10, 17, 19, 27, 30, 1, time.Local),
+			fsp:          4,
+			expectedTime: time.Date(2015, 3, 29, 22, 50, 44, 123400000, time.Local),
+		},
+		&dateTime2TestCase{
+			buff: []byte{
+				0x08, 0x00, 0x00, 0x01,
+				0x99, 0x87, 0x23, 0x36, 0xde, 0x00, 0x00, 0x01,
+			},
+			fsp:          6,
+			expectedTime: time.Date(2010, 10, 17, 19, 27, 30, 1000, time.Local),
+		},
+		&dateTime2TestCase{
+			buff: []byte{
+				0x05, 0x00, 0x00, 0x01,
+				0x80, 0x00, 0x00, 0x00, 0x00,
+			},
+			fsp:          0,
+			expectedTime: time.Time{}.In(time.Local),
 		},
 	}
 
@@ -850,7 +889,10 @@ func TestReadDateTime2(t *testing.T) {
 		reader := newPackReader(bytes.NewBuffer(testCase.buff))
 		pack, _ := reader.readNextPack()
 
-		result := pack.readDateTime2(0)
+		result, err := pack.readDateTime2(testCase.fsp, time.Local)
+		if err != nil {
+			t.Fatal("Read date time fail at test", i, err)
+		}
 
 		if !testCase.expectedTime.Equal(result) {
 			t.Fatal(
@@ -860,7 +902,133 @@ func TestReadDateTime2(t *testing.T) {
 			)
 		}
 	}
-}*/
+
+	reader := newPackReader(bytes.NewBuffer([]byte{0x06, 0x00, 0x00, 0x01, 0x99, 0x87, 0x23, 0x36, 0xde, 0x00}))
+	pack, _ := reader.readNextPack()
+	if _, err := pack.readDateTime2(6, time.Local); err != SHORT_PACKET_ERR {
+		t.Fatal("Incorrect short fraction error", "expected", SHORT_PACKET_ERR, "got", err)
+	}
+}
+
+func TestReadTimestamp2(t *testing.T) {
+	type timestamp2TestCase struct {
+		buff         []byte
+		fsp          uint8
+		expectedTime time.Time
+	}
+
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	seconds := int64(1427669444)
+
+	testCases := []*timestamp2TestCase{
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4}, 0, time.Unix(seconds, 0)},
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4, 0x32}, 1, time.Unix(seconds, 500000000)},
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4, 0x0c}, 2, time.Unix(seconds, 120000000)},
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4, 0x04, 0xce}, 3, time.Unix(seconds, 123000000)},
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4, 0x04, 0xd2}, 4, time.Unix(seconds, 123400000)},
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4, 0x01, 0xe2, 0x3a}, 5, time.Unix(seconds, 123450000)},
+		&timestamp2TestCase{[]byte{0x55, 0x18, 0x81, 0xc4, 0x01, 0xe2, 0x40}, 6, time.Unix(seconds, 123456000)},
+		&timestamp2TestCase{[]byte{0x00, 0x00, 0x00, 0x00}, 0, time.Time{}},
+		&timestamp2TestCase{[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, 6, time.Time{}},
+	}
+
+	for i, testCase := range testCases {
+		pack := newPackWithBuff(testCase.buff)
+
+		result, err := pack.readTimestamp2(testCase.fsp, loc)
+		if err != nil {
+			t.Fatal("Read timestamp fail at test", i, err)
+		}
+
+		if !testCase.expectedTime.Equal(result) || result.Location() != loc {
+			t.Fatal(
+				"incorrect timestamp at test", i,
+				"expected", testCase.expectedTime.In(loc),
+				"got", result,
+			)
+		}
+
+		if pack.Len() != 0 {
+			t.Fatal("Incorrect unread bytes at test", i, "expected", 0, "got", pack.Len())
+		}
+	}
+}
+
+func TestReadTime2(t *testing.T) {
+	type time2TestCase struct {
+		buff         []byte
+		fsp          uint8
+		expectedTime time.Duration
+	}
+
+	testCases := []*time2TestCase{
+		&time2TestCase{
+			[]byte{0x80, 0xc8, 0xb8}, 0,
+			12*time.Hour + 34*time.Minute + 56*time.Second,
+		},
+		&time2TestCase{
+			[]byte{0x4b, 0x91, 0x05}, 0,
+			-(838*time.Hour + 59*time.Minute + 59*time.Second),
+		},
+		&time2TestCase{
+			[]byte{0x80, 0x10, 0x83, 0x32}, 1,
+			time.Hour + 2*time.Minute + 3*time.Second + 500*time.Millisecond,
+		},
+		&time2TestCase{
+			[]byte{0x7f, 0xff, 0xfe, 0xce}, 2,
+			-(time.Second + 500*time.Millisecond),
+		},
+		&time2TestCase{
+			[]byte{0x81, 0x7e, 0xfb, 0x04, 0xce}, 3,
+			23*time.Hour + 59*time.Minute + 59*time.Second + 123*time.Millisecond,
+		},
+		&time2TestCase{
+			[]byte{0x79, 0xbf, 0xff, 0xfb, 0x2e}, 4,
+			-(100*time.Hour + 123400*time.Microsecond),
+		},
+		&time2TestCase{
+			[]byte{0x80, 0x00, 0x00, 0x00, 0x30, 0x34}, 5,
+			12340 * time.Microsecond,
+		},
+		&time2TestCase{
+			[]byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff}, 6,
+			-time.Microsecond,
+		},
+		&time2TestCase{
+			[]byte{0xb4, 0x6e, 0xfb, 0x0f, 0x42, 0x3f}, 6,
+			838*time.Hour + 59*time.Minute + 59*time.Second + 999999*time.Microsecond,
+		},
+		&time2TestCase{
+			[]byte{0x80, 0x00, 0x00}, 0,
+			0,
+		},
+	}
+
+	for i, testCase := range testCases {
+		pack := newPackWithBuff(testCase.buff)
+
+		result, err := pack.readTime2(testCase.fsp)
+		if err != nil {
+			t.Fatal("Read time fail at test", i, err)
+		}
+
+		if result != testCase.expectedTime {
+			t.Fatal(
+				"incorrect time at test", i,
+				"expected", testCase.expectedTime,
+				"got", result,
+			)
+		}
+
+		if pack.Len() != 0 {
+			t.Fatal("Incorrect unread bytes at test", i, "expected", 0, "got", pack.Len())
+		}
+	}
+
+	if _, err := newPackWithBuff([]byte{0x80, 0x00, 0x00}).readTime2(6); err != SHORT_PACKET_ERR {
+		t.Fatal("Incorrect short time error", "expected", SHORT_PACKET_ERR, "got", err)
+	}
+}
 
 func TestReadTime(t *testing.T) {
 
@@ -906,7 +1074,10 @@ func TestReadTime(t *testing.T) {
 		reader := newPackReader(bytes.NewBuffer(testCase.buff))
 		pack, _ := reader.readNextPack()
 
-		result := pack.readTime()
+		result, err := pack.readTime()
+		if err != nil {
+			t.Fatal("Read time fail at test", i, err)
+		}
 
 		if result != testCase.expectedTime {
 			t.Fatal(
@@ -972,7 +1143,11 @@ func TestNewDecimal(t *testing.T) {
 		reader := newPackReader(bytes.NewBuffer(testCase.buff))
 		pack, _ := reader.readNextPack()
 
-		result, _ := pack.readNewDecimal(testCase.precission, testCase.scale).Float64()
+		decimal, err := pack.readNewDecimal(testCase.precission, testCase.scale)
+		if err != nil {
+			t.Fatal("Read decimal fail at test", i, err)
+		}
+		result, _ := decimal.Float64()
 
 		if result != testCase.expectedDecimal {
 			t.Fatal(
@@ -1015,3 +1190,68 @@ func TestDecimalBinarySize(t *testing.T) {
 		}
 	}
 }
+
+func FuzzReadNextPack(f *testing.F) {
+	//seeds from TestReadPackTotal, TestOkPacketError and TestEOFPacket
+	f.Add([]byte{0x03, 0x00, 0x00, 0x0a, 0x01, 0x02, 0x03, 0x03, 0x00, 0x00, 0x0b, 0x04, 0x05, 0x06}, 0)
+	f.Add([]byte{
+		0x17, 0x00, 0x00, 0x01, 0xff, 0x48, 0x04,
+		0x23, 0x48, 0x59, 0x30, 0x30, 0x30, 0x4e, 0x6f, 0x20, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x20, 0x75, 0x73,
+		0x65, 0x64,
+	}, 0)
+	f.Add([]byte{0x05, 0x00, 0x00, 0x01, 0xFE, 0x00, 0x00, 0x02, 0x00}, 4)
+
+	f.Fuzz(func(t *testing.T, data []byte, addLength int) {
+		reader := newPackReader(bytes.NewBuffer(data))
+		for {
+			pack, err := reader.readNextPackWithAdditionalLength(addLength)
+			if err != nil {
+				return
+			}
+
+			pack.isError()
+			pack.isEOF()
+
+			var value uint64
+			var null bool
+			pack.readIntLengthOrNil(&value, &null)
+			pack.readStringLength()
+			pack.readNilString()
+		}
+	})
+}
+
+func FuzzNewDecimal(f *testing.F) {
+	//seeds from TestNewDecimal
+	f.Add([]byte{0x9e, 0x61, 0x42}, 6, 2)
+	f.Add([]byte{0x84, 0xd2, 0x38}, 6, 2)
+	f.Add([]byte{0x80, 0x00, 0x00, 0x01, 0x4d}, 10, 0)
+	f.Add([]byte{0x7f, 0xff, 0xff, 0xfe, 0xb2}, 10, 0)
+
+	f.Fuzz(func(t *testing.T, data []byte, precission, scale int) {
+		decimal, err := newPackWithBuff(data).readNewDecimal(precission, scale)
+		if err == nil && decimal == nil {
+			t.Fatal("Incorrect decimal", "expected", "value", "got", nil)
+		}
+	})
+}
+
+func FuzzTemporal(f *testing.F) {
+	//seeds from TestReadDate, TestReadDateTime, TestReadDateTime2 and TestReadTime
+	f.Add([]byte{0x78, 0xbc, 0x0f}, uint8(0))
+	f.Add([]byte{0x59, 0xe3, 0xe4, 0x77, 0x51, 0x12, 0x00, 0x00}, uint8(0))
+	f.Add([]byte{0x99, 0x95, 0xbb, 0x6c, 0xac, 0x04, 0xd2}, uint8(4))
+	f.Add([]byte{0x99, 0x87, 0x23, 0x36, 0xde, 0x00, 0x00, 0x01}, uint8(6))
+	f.Add([]byte{0x0c, 0x01, 0x78, 0x00, 0x00, 0x00, 0x13, 0x1b, 0x1e, 0x01, 0x00, 0x00, 0x00}, uint8(0))
+	f.Add([]byte{0x08, 0x00, 0x78, 0x00, 0x00, 0x00, 0x13, 0x1b, 0x1e}, uint8(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, fsp uint8) {
+		newPackWithBuff(data).readDate(time.UTC)
+		newPackWithBuff(data).readDateTime(time.UTC)
+		newPackWithBuff(data).readTimestamp(time.UTC)
+		newPackWithBuff(data).readTimestamp2(fsp, time.UTC)
+		newPackWithBuff(data).readDateTime2(fsp, time.UTC)
+		newPackWithBuff(data).readTime()
+		newPackWithBuff(data).readTime2(fsp)
+	})
+}
@@ -1,6 +1,14 @@
 package myreplication
 
-import "crypto/sha1"
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
 
 //copied from from github.com/ziutek/mymysql/native/passwd.go
 func encryptedPasswd(password string, scramble []byte) (out []byte) {
@@ -29,3 +37,68 @@ func encryptedPasswd(password string, scramble []byte) (out []byte) {
 	}
 	return
 }
+
+// caching_sha2_password fast auth token
+// XOR(SHA256(password), SHA256(SHA256(SHA256(password)), scramble))
+func scrambleSHA256Password(password string, scramble []byte) (out []byte) {
+	if len(password) == 0 {
+		return
+	}
+
+	crypt := sha256.New()
+	crypt.Write([]byte(password))
+	stg1Hash := crypt.Sum(nil)
+
+	crypt.Reset()
+	crypt.Write(stg1Hash)
+	stg2Hash := crypt.Sum(nil)
+
+	crypt.Reset()
+	crypt.Write(stg2Hash)
+	crypt.Write(scramble)
+	stg3Hash := crypt.Sum(nil)
+
+	out = make([]byte, len(stg1Hash))
+	for ii := range stg1Hash {
+		out[ii] = stg1Hash[ii] ^ stg3Hash[ii]
+	}
+	return
+}
+
+// full auth without TLS: the nul terminated password is XORed with the scramble
+// and encrypted with the server RSA public key
+func encryptPasswordRSA(password string, scramble []byte, pemKey []byte) ([]byte, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("incorrect server public key")
+	}
+
+	var publicKey *rsa.PublicKey
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("server public key is not RSA")
+		}
+		publicKey = rsaKey
+	}
+
+	plain := append([]byte(password), 0)
+	if len(scramble) > 0 {
+		for i := range plain {
+			plain[i] ^= scramble[i%len(scramble)]
+		}
+	}
+
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, plain, nil)
+}
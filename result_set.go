@@ -30,7 +30,9 @@ type (
 )
 
 var (
-	EOF_ERR = errors.New("EOF")
+	EOF_ERR                  = errors.New("EOF")
+	PACKET_SEQUENCE_ERR      = errors.New("incorrect packet sequence")
+	MALFORMED_RESULT_SET_ERR = errors.New("malformed result set packet")
 )
 
 func (rs *resultSet) setReader(reader *packReader) {
@@ -61,7 +63,7 @@ func (rs *resultSet) init() error {
 	}
 
 	if null {
-		panic("Column count got panic")
+		return MALFORMED_RESULT_SET_ERR
 	}
 
 	if columnCount == 0 {
@@ -82,11 +84,13 @@ func (rs *resultSet) init() error {
 		}
 
 		if columnPack.getSequence() != sequenceId {
-			panic("Incorrect sequence")
+			return PACKET_SEQUENCE_ERR
 		}
 		sequenceId++
 
-		rs.columns[i] = packToColumnPack(columnPack)
+		if rs.columns[i], err = packToColumnPack(columnPack); err != nil {
+			return err
+		}
 	}
 	pack, err = rs.reader.readNextPack()
 
@@ -95,7 +99,7 @@ func (rs *resultSet) init() error {
 	}
 
 	if sequenceId != pack.getSequence() {
-		panic("Incorrect sequence")
+		return PACKET_SEQUENCE_ERR
 	}
 
 	rs.finish = false
@@ -104,7 +108,7 @@ func (rs *resultSet) init() error {
 	eof, _ := pack.ReadByte()
 
 	if eof != _MYSQL_EOF {
-		panic("Incorrect EOF packet")
+		return MALFORMED_RESULT_SET_ERR
 	}
 	pack.readUint16(&rs.lastWarning)
 	pack.readUint16(&rs.lastStatus)
@@ -134,7 +138,10 @@ func (rs *resultSet) initFieldList() error {
 			break
 		}
 
-		columnDef := packToColumnPack(columnPack)
+		columnDef, err := packToColumnPack(columnPack)
+		if err != nil {
+			return err
+		}
 		rs.columns = append(rs.columns, columnDef)
 	}
 
@@ -143,7 +150,7 @@ func (rs *resultSet) initFieldList() error {
 	return nil
 }
 
-func packToColumnPack(columnPack *pack) *columnSet {
+func packToColumnPack(columnPack *pack) (*columnSet, error) {
 	cs := &columnSet{}
 	cs.catalog, _ = columnPack.readStringLength()
 	cs.schema, _ = columnPack.readStringLength()
@@ -154,14 +161,14 @@ func packToColumnPack(columnPack *pack) *columnSet {
 	//filler
 	filler, _ := columnPack.ReadByte()
 	if filler != 0x0c {
-		panic("incorrect filler")
+		return nil, MALFORMED_RESULT_SET_ERR
 	}
 	columnPack.readUint16(&cs.character_set)
 	columnPack.readUint32(&cs.column_length)
 	cs.column_type, _ = columnPack.ReadByte()
 	columnPack.readUint16(&cs.flags)
 	cs.decimals, _ = columnPack.ReadByte()
-	return cs
+	return cs, nil
 }
 
 func (rs *resultSet) nextRow() (*pack, error) {
@@ -176,7 +183,7 @@ func (rs *resultSet) nextRow() (*pack, error) {
 	}
 
 	if pack.getSequence() != rs.sequenceId {
-		panic("Incorrect seuence")
+		return nil, PACKET_SEQUENCE_ERR
 	}
 	rs.sequenceId++
 
@@ -1,41 +1,67 @@
 package myreplication
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"strings"
+	"time"
 )
 
 type rowsEvent struct {
 	*eventLogHeader
 	tableMapEvent    *TableMapEvent
 	postHeaderLength byte
+	location         *time.Location
 
 	tableId   uint64
-	flags     uint16
+	rowsFlags uint16
 	extraData []byte
 	values    [][]*RowsEventValue
 	newValues [][]*RowsEventValue
 }
 
-func (event *rowsEvent) read(pack *pack) {
-	isUpdateEvent := event.EventType == _UPDATE_ROWS_EVENTv1 || event.EventType == _UPDATE_ROWS_EVENTv2
+func (event *rowsEvent) read(pack *pack) error {
+	if event.tableMapEvent == nil {
+		return errors.New("rows event without TABLE_MAP_EVENT")
+	}
+
+	//the rows of a filtered table are not decoded
+	if event.tableMapEvent.skipped {
+		return nil
+	}
+
+	isPartialEvent := event.EventType == _PARTIAL_UPDATE_ROWS_EVENT
+	isUpdateEvent := event.EventType == _UPDATE_ROWS_EVENTv1 || event.EventType == _UPDATE_ROWS_EVENTv2 || isPartialEvent
 
 	if event.postHeaderLength == 6 {
 		var tableId uint32
-		pack.readUint32(&tableId)
+		if err := pack.readUint32(&tableId); err != nil {
+			return err
+		}
 		event.tableId = uint64(tableId)
-	} else {
-		pack.readSixByteUint64(&event.tableId)
+	} else if err := pack.readSixByteUint64(&event.tableId); err != nil {
+		return err
 	}
 
-	pack.readUint16(&event.Flags)
+	if err := pack.readUint16(&event.rowsFlags); err != nil {
+		return err
+	}
 
 	//If row event == 2
-	if event.EventType >= _WRITE_ROWS_EVENTv2 && event.EventType <= _DELETE_ROWS_EVENTv2 {
+	if event.EventType >= _WRITE_ROWS_EVENTv2 && event.EventType <= _DELETE_ROWS_EVENTv2 || isPartialEvent {
 		var extraDataLength uint16
-		pack.readUint16(&extraDataLength)
-		extraDataLength -= 2
-		event.extraData = pack.Next(int(extraDataLength))
+		if err := pack.readUint16(&extraDataLength); err != nil {
+			return err
+		}
+		if extraDataLength < 2 {
+			return EVENT_TOO_SHORT_ERR
+		}
+		extraData, err := pack.next(int(extraDataLength - 2))
+		if err != nil {
+			return err
+		}
+		event.extraData = extraData
 	}
 
 	var (
@@ -43,7 +69,16 @@ func (event *rowsEvent) read(pack *pack) {
 		isNull      bool
 	)
 
-	pack.readIntLengthOrNil(&columnCount, &isNull)
+	if err := pack.readIntLengthOrNil(&columnCount, &isNull); err != nil {
+		return err
+	}
+	if columnCount != uint64(len(event.tableMapEvent.Columns)) {
+		return fmt.Errorf("rows event has %d columns, table map %d", columnCount, len(event.tableMapEvent.Columns))
+	}
+	//a row of no columns would consume no bytes
+	if columnCount == 0 {
+		return errors.New("rows event without columns")
+	}
 	bitMapLength := int((columnCount + 7) / 8)
 
 	var columnPreset, columnPresentBitmap1, columnPresentBitmap2, nullBitmap []byte
@@ -51,22 +86,76 @@ func (event *rowsEvent) read(pack *pack) {
 	if isUpdateEvent {
 		columnPresentBitmap2 = pack.Next(bitMapLength)
 	}
+	if len(columnPresentBitmap1) < bitMapLength || isUpdateEvent && len(columnPresentBitmap2) < bitMapLength {
+		return EVENT_TOO_SHORT_ERR
+	}
+
+	//the null bitmap of a row has a bit per present column
+	presentCount1, presentCount2 := 0, 0
+	for i := 0; i < int(columnCount); i++ {
+		if isTrue(i, columnPresentBitmap1) {
+			presentCount1++
+		}
+		if isUpdateEvent && isTrue(i, columnPresentBitmap2) {
+			presentCount2++
+		}
+	}
+	if presentCount1 == 0 || isUpdateEvent && presentCount2 == 0 {
+		return errors.New("rows event without present columns")
+	}
 
 	event.values = [][]*RowsEventValue{}
 	event.newValues = [][]*RowsEventValue{}
 
+	loc := event.location
+	if loc == nil {
+		loc = time.Local
+	}
+
 	switcher := true
 
+	jsonColumnCount := 0
+	for _, column := range event.tableMapEvent.Columns {
+		if column.Type == MYSQL_TYPE_JSON {
+			jsonColumnCount++
+		}
+	}
+
 	for {
-		nullBitmap = pack.Next(bitMapLength)
+		presentCount := presentCount1
+		columnPreset = columnPresentBitmap1
+		if !switcher {
+			presentCount = presentCount2
+			columnPreset = columnPresentBitmap2
+		}
+
+		//the after image of a partial update tells the JSON columns holding diffs
+		var partialBitmap []byte
+		if isPartialEvent && !switcher {
+			valueOptions, err := pack.readIntLength()
+			if err != nil {
+				return err
+			}
+			if valueOptions&_PARTIAL_JSON_UPDATES != 0 {
+				if partialBitmap, err = pack.next((jsonColumnCount + 7) / 8); err != nil {
+					return err
+				}
+			}
+		}
+
+		nullBitmap = pack.Next((presentCount + 7) / 8)
+		if len(nullBitmap) < (presentCount+7)/8 {
+			return EVENT_TOO_SHORT_ERR
+		}
 
 		row := []*RowsEventValue{}
+		jsonIndex := 0
 		for i, column := range event.tableMapEvent.Columns {
-
-			if switcher {
-				columnPreset = columnPresentBitmap1
-			} else {
-				columnPreset = columnPresentBitmap2
+			//the partial bitmap has a bit per JSON column, present or not
+			isPartial := false
+			if column.Type == MYSQL_TYPE_JSON {
+				isPartial = partialBitmap != nil && isTrue(jsonIndex, partialBitmap)
+				jsonIndex++
 			}
 
 			if !isTrue(i, columnPreset) {
@@ -77,72 +166,123 @@ func (event *rowsEvent) read(pack *pack) {
 				columnId: i,
 				_type:    column.Type,
 			}
-			if isTrue(i, nullBitmap) {
+			if isTrue(len(row), nullBitmap) {
 				value.value = nil
 				value.isNull = true
 			} else {
+				var err error
 				switch column.Type {
 				case MYSQL_TYPE_TINY:
-					value.value, _ = pack.ReadByte()
+					var val uint8
+					err = pack.readUint8(&val)
+					if column.Unsigned {
+						value.value = val
+					} else {
+						value.value = int8(val)
+					}
 				case MYSQL_TYPE_SHORT:
 					var val uint16
-					pack.readUint16(&val)
-					value.value = val
+					err = pack.readUint16(&val)
+					if column.Unsigned {
+						value.value = val
+					} else {
+						value.value = int16(val)
+					}
 				case MYSQL_TYPE_LONG:
 					var val uint32
-					pack.readUint32(&val)
-					value.value = val
+					err = pack.readUint32(&val)
+					if column.Unsigned {
+						value.value = val
+					} else {
+						value.value = int32(val)
+					}
 				case MYSQL_TYPE_INT24:
 					var val uint32
-					pack.readThreeByteUint32(&val)
-					value.value = val
+					err = pack.readThreeByteUint32(&val)
+					if column.Unsigned {
+						value.value = val
+					} else {
+						//sign extension of the 24 bits
+						value.value = int32(val<<8) >> 8
+					}
 				case MYSQL_TYPE_FLOAT:
 					var val uint32
-					pack.readUint32(&val)
+					err = pack.readUint32(&val)
 					value.value = float32(math.Float32frombits(val))
 				case MYSQL_TYPE_DOUBLE:
 					var val uint64
-					pack.readUint64(&val)
+					err = pack.readUint64(&val)
 					value.value = math.Float64frombits(val)
 				case MYSQL_TYPE_LONGLONG:
 					var val uint64
-					pack.readUint64(&val)
-					value.value = val
-				case MYSQL_TYPE_STRING,MYSQL_TYPE_VARCHAR:
-					var val string
+					err = pack.readUint64(&val)
+					if column.Unsigned {
+						value.value = val
+					} else {
+						value.value = int64(val)
+					}
+				case MYSQL_TYPE_STRING, MYSQL_TYPE_VARCHAR:
 					if column.MaxLen > 255 {
-						val, _ = pack.readStringBySize(2)
+						value.value, err = pack.readStringBySize(2)
 					} else {
-						val, _ = pack.readStringBySize(1)
+						value.value, err = pack.readStringBySize(1)
 					}
-					value.value = string(val)
 				case MYSQL_TYPE_DECIMAL, MYSQL_TYPE_NEWDECIMAL:
-					value.value = pack.readNewDecimal(int(column.Precision), int(column.Decimals))
-				case MYSQL_TYPE_TINY_BLOB, MYSQL_TYPE_MEDIUM_BLOB, MYSQL_TYPE_BLOB, MYSQL_TYPE_LONG_BLOB:
-					value.value, _ = pack.readStringBySize(int(column.LenSize))
+					value.value, err = pack.readNewDecimal(int(column.Precision), int(column.Decimals))
+				case MYSQL_TYPE_TINY_BLOB, MYSQL_TYPE_MEDIUM_BLOB, MYSQL_TYPE_BLOB, MYSQL_TYPE_LONG_BLOB, MYSQL_TYPE_GEOMETRY:
+					value.value, err = pack.readStringBySize(int(column.LenSize))
+				case MYSQL_TYPE_JSON:
+					var data string
+					if data, err = pack.readStringBySize(int(column.LenSize)); err != nil {
+						break
+					}
+					if isPartial {
+						value.value, err = readJSONDiffs([]byte(data))
+					} else {
+						value.value, err = decodeJSONBinary([]byte(data))
+					}
 				case MYSQL_TYPE_DATE:
-					value.value = pack.readDate()
+					value.value, err = pack.readDate(loc)
 				case MYSQL_TYPE_DATETIME:
-					value.value = pack.readDateTime()
+					value.value, err = pack.readDateTime(loc)
 				case MYSQL_TYPE_TIMESTAMP:
-					value.value = pack.readTimestamp()
+					value.value, err = pack.readTimestamp(loc)
 				case MYSQL_TYPE_TIME:
-					value.value = pack.readTime()
+					value.value, err = pack.readTime()
 				case MYSQL_TYPE_YEAR:
-					b, _ := pack.ReadByte()
+					var b uint8
+					err = pack.readUint8(&b)
 					value.value = 1900 + uint32(b)
 
 					// for new date format
 				case MYSQL_TYPE_DATETIME2:
-					value.value = pack.readDateTime2(column.Fsp)
-				case MYSQL_TYPE_ENUM, MYSQL_TYPE_SET, MYSQL_TYPE_GEOMETRY, MYSQL_TYPE_BIT:
-					enumValue := column.EnumValues
-					var arr []string
-					for _,s := range enumValue {
-						arr = append(arr, s)
+					value.value, err = pack.readDateTime2(column.Fsp, loc)
+				case MYSQL_TYPE_TIMESTAMP2:
+					value.value, err = pack.readTimestamp2(column.Fsp, loc)
+				case MYSQL_TYPE_TIME2:
+					value.value, err = pack.readTime2(column.Fsp)
+				case MYSQL_TYPE_ENUM:
+					var index uint64
+					if index, err = pack.readUint64BySize(int(column.Size)); err == nil {
+						value.value = column.enumValue(index)
+					}
+				case MYSQL_TYPE_SET:
+					var bits uint64
+					if bits, err = pack.readUint64BySize(int(column.Size)); err == nil {
+						value.value = column.setValue(bits)
 					}
-					columnId := value.GetColumnId()
-					value.value = arr[columnId-1]
+				case MYSQL_TYPE_BIT:
+					var bits []byte
+					if bits, err = pack.next(column.Bytes); err == nil {
+						var val uint64
+						for _, b := range bits {
+							val = val<<8 | uint64(b)
+						}
+						value.value = val
+					}
+				}
+				if err != nil {
+					return err
 				}
 			}
 			row = append(row, value)
@@ -162,6 +302,7 @@ func (event *rowsEvent) read(pack *pack) {
 			break
 		}
 	}
+	return nil
 }
 
 func (event *rowsEvent) GetSchema() string {
@@ -176,6 +317,18 @@ func (event *rowsEvent) GetRows() [][]*RowsEventValue {
 	return event.values
 }
 
+// GetRowsFlags returns the flags of the rows event post header, like the end of
+// statement flag, the flags of the event header are in Header
+func (event *rowsEvent) GetRowsFlags() uint16 {
+	return event.rowsFlags
+}
+
+// GetColumns returns the columns of the table, indexed by the column id of the
+// values
+func (event *rowsEvent) GetColumns() []*Column {
+	return event.tableMapEvent.Columns
+}
+
 func isTrue(columnId int, bitmap []byte) bool {
 	return (bitmap[columnId/8]>>uint8(columnId%8))&1 == 1
 }
@@ -205,6 +358,22 @@ type RowsEventValue struct {
 	_type    byte
 }
 
+type (
+	// EnumValue is a decoded ENUM value, Label is empty for the index 0 of an
+	// invalid value and when the column has no label for the index
+	EnumValue struct {
+		Index uint16
+		Label string
+	}
+
+	// SetValue is a decoded SET value, Labels is nil when the column has no
+	// label for a set bit
+	SetValue struct {
+		Bits   uint64
+		Labels []string
+	}
+)
+
 func (event *RowsEventValue) GetType() byte {
 	return event._type
 }
@@ -221,6 +390,13 @@ func (event *RowsEventValue) GetColumnId() int {
 	return event.columnId
 }
 
+// IsPartial is true for a JSON value of a partial update, its value is the
+// []*JSONDiff to apply to the document of the before image
+func (event *RowsEventValue) IsPartial() bool {
+	_, ok := event.value.([]*JSONDiff)
+	return ok
+}
+
 type (
 	TableMapEvent struct {
 		*eventLogHeader
@@ -233,28 +409,32 @@ type (
 		ctrConn       *Connection
 		schemaColumns []*SchemaColumn
 		tableMap      map[uint64]*Table
+		tableFilter   *TableFilter
+		skipped       bool
 	}
 
 	Column struct {
-		Type byte
-		//	Nullable   bool
-		Name       string
-		Collation  string
-		Charset    string
-		Comment    string
-		Unsigned   bool
-		IsBool     bool
-		IsPrimary  bool
-		MaxLen     uint16
-		LenSize    uint8
-		Precision  uint8
-		Decimals   uint8
-		Size       uint8
-		Bits       uint8
-		Bytes      int
-		Fsp        uint8
-		EnumValues []string
-		SetValues  []string
+		Type         byte
+		Nullable     bool
+		Name         string
+		Collation    string
+		CollationId  uint64
+		Charset      string
+		Comment      string
+		Unsigned     bool
+		IsBool       bool
+		IsPrimary    bool
+		MaxLen       uint16
+		LenSize      uint8
+		Precision    uint8
+		Decimals     uint8
+		Size         uint8
+		Bits         uint8
+		Bytes        int
+		Fsp          uint8
+		EnumValues   []string
+		SetValues    []string
+		GeometryType uint8
 	}
 
 	SchemaColumn struct {
@@ -267,32 +447,22 @@ type (
 	}
 )
 
-func newColumn(pack *pack, colType byte , column *SchemaColumn) (*Column, error) {
+func newColumn(pack *pack, colType byte) (*Column, error) {
 	this := &Column{}
 
 	this.Type = colType
-	/*	this.Name = column.COLUMN_NAME
-		this.Collation = column.COLLATION_NAME
-		this.Charset = column.CHARACTER_SET_NAME
-		this.Comment = column.COLUMN_COMMENT
-
-		if column.COLUMN_KEY == "PRI" {
-			this.IsPrimary = true
-		}
 
-		if strings.Contains(column.COLUMN_TYPE, `unsigned`) {
-			this.Unsigned = true
-		}
-	*/
 	var err error
 	switch this.Type {
 	case MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_STRING:
-		this.readStringMetaData(pack , column)
+		if err = this.readStringMetaData(pack); err != nil {
+			return nil, err
+		}
 	case MYSQL_TYPE_VARCHAR:
 		if err = pack.readUint16(&this.MaxLen); err != nil {
 			return nil, err
 		}
-	case MYSQL_TYPE_BLOB, MYSQL_TYPE_GEOMETRY:
+	case MYSQL_TYPE_BLOB, MYSQL_TYPE_GEOMETRY, MYSQL_TYPE_JSON:
 		if err = pack.readUint8(&this.LenSize); err != nil {
 			return nil, err
 		}
@@ -315,8 +485,12 @@ func newColumn(pack *pack, colType byte , column *SchemaColumn) (*Column, error)
 		if err = pack.readUint8(&bytes); err != nil {
 			return nil, err
 		}
-		this.Bits = bits*8 + bits
-		this.Bytes = int((bytes + 7) / 8)
+		//bits is the length modulo 8, bytes the length divided by 8
+		if bits > 7 || int(bytes)*8+int(bits) > 64 {
+			return nil, fmt.Errorf("incorrect BIT column length %d", int(bytes)*8+int(bits))
+		}
+		this.Bits = bytes*8 + bits
+		this.Bytes = (int(this.Bits) + 7) / 8
 	case MYSQL_TYPE_TIMESTAMP2, MYSQL_TYPE_DATETIME2, MYSQL_TYPE_TIME2:
 		if err = pack.readUint8(&this.Fsp); err != nil {
 			return nil, err
@@ -330,7 +504,7 @@ func newColumn(pack *pack, colType byte , column *SchemaColumn) (*Column, error)
 	return this, nil
 }
 
-func (c *Column) readStringMetaData(pack *pack , column *SchemaColumn) error {
+func (c *Column) readStringMetaData(pack *pack) error {
 	var b1, b2 uint8
 	var err error
 	if err = pack.readUint8(&b1); err != nil {
@@ -341,17 +515,13 @@ func (c *Column) readStringMetaData(pack *pack , column *SchemaColumn) error {
 		return err
 	}
 
-	meta := uint16(b1<<8) + uint16(b2)
+	meta := uint16(b1)<<8 + uint16(b2)
 	real_type := byte(meta >> 8)
-	if(strings.Contains(column.COLUMN_TYPE, "enum")) {
-		real_type = 0xf7;
-	}
 
 	switch real_type {
 	case MYSQL_TYPE_ENUM, MYSQL_TYPE_SET:
 		c.Type = real_type
 		c.Size = uint8(meta & 0x00ff)
-		c.readEnumData(column)
 	default:
 		c.MaxLen = (((meta >> 4) & 0x300) ^ 0x300) + (meta & 0x00ff)
 	}
@@ -359,6 +529,81 @@ func (c *Column) readStringMetaData(pack *pack , column *SchemaColumn) error {
 	return nil
 }
 
+// applySchema sets the column information of the information_schema query,
+// without it the integers are signed, the MySQL default
+func (c *Column) applySchema(column *SchemaColumn) {
+	c.Name = column.COLUMN_NAME
+	c.Collation = schemaString(column.COLLATION_NAME)
+	c.Charset = schemaString(column.CHARACTER_SET_NAME)
+	c.Comment = column.COLUMN_COMMENT
+
+	if column.COLUMN_KEY == "PRI" {
+		c.IsPrimary = true
+	}
+
+	if strings.Contains(column.COLUMN_TYPE, `unsigned`) {
+		c.Unsigned = true
+	}
+
+	c.readEnumData(column)
+}
+
+func schemaString(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return ""
+}
+
+// enumValue returns the ENUM index with its label, 0 is the empty string of an
+// invalid value
+func (c *Column) enumValue(index uint64) *EnumValue {
+	value := &EnumValue{Index: uint16(index)}
+	if index > 0 && index <= uint64(len(c.EnumValues)) {
+		value.Label = c.EnumValues[index-1]
+	}
+	return value
+}
+
+// setValue returns the SET bitmask with the labels of its bits
+func (c *Column) setValue(bits uint64) *SetValue {
+	value := &SetValue{Bits: bits}
+	if len(c.SetValues) == 0 || len(c.SetValues) < 64 && bits>>uint(len(c.SetValues)) != 0 {
+		return value
+	}
+
+	value.Labels = []string{}
+	for i, label := range c.SetValues {
+		if bits>>uint(i)&1 == 1 {
+			value.Labels = append(value.Labels, label)
+		}
+	}
+	return value
+}
+
+// BitString formats a BIT value of the column with a digit per bit, the most
+// significant first
+func (c *Column) BitString(value uint64) string {
+	digits := make([]byte, c.Bits)
+	for i := range digits {
+		digits[len(digits)-1-i] = '0' + byte(value>>uint(i)&1)
+	}
+	return string(digits)
+}
+
+// BitBools returns the bits of a BIT value of the column, index i is bit i
+// counted from the least significant one
+func (c *Column) BitBools(value uint64) []bool {
+	bits := make([]bool, c.Bits)
+	for i := range bits {
+		bits[i] = value>>uint(i)&1 == 1
+	}
+	return bits
+}
+
 func (c *Column) readEnumData(column *SchemaColumn) {
 	enum := column.COLUMN_TYPE
 	if c.Type == MYSQL_TYPE_ENUM {
@@ -376,53 +621,95 @@ func (c *Column) readEnumData(column *SchemaColumn) {
 	}
 }
 
-func (event *TableMapEvent) read(pack *pack) {
-	pack.readSixByteUint64(&event.TableId)
-	pack.readUint16(&event.Flags)
-
-	schemaLength, _ := pack.ReadByte()
-	event.SchemaName = string(pack.Next(int(schemaLength)))
-	filler, _ := pack.ReadByte()
-	if filler != 0 {
-		panic("incorrect filler")
+func (event *TableMapEvent) read(pack *pack) error {
+	if err := pack.readSixByteUint64(&event.TableId); err != nil {
+		return err
+	}
+	if err := pack.readUint16(&event.Flags); err != nil {
+		return err
 	}
 
-	tableLength, _ := pack.ReadByte()
-	event.TableName = string(pack.Next(int(tableLength)))
-	filler, _ = pack.ReadByte()
-	if filler != 0 {
-		panic("incorrect filler")
+	schemaName, err := pack.readStringBySize(1)
+	if err != nil {
+		return err
+	}
+	event.SchemaName = schemaName
+	filler, err := pack.ReadByte()
+	if err != nil || filler != 0 {
+		return errors.New("incorrect TABLE_MAP_EVENT schema filler")
 	}
 
-	// get schema info
+	tableName, err := pack.readStringBySize(1)
+	if err != nil {
+		return err
+	}
+	event.TableName = tableName
+	filler, err = pack.ReadByte()
+	if err != nil || filler != 0 {
+		return errors.New("incorrect TABLE_MAP_EVENT table filler")
+	}
 
-	var err error
-	if _, ok := event.tableMap[event.TableId]; ok {
-		event.schemaColumns = event.tableMap[event.TableId].SchemaColumns
-	} else if event.schemaColumns, err = event.ctrConn.getSchemaColumns(event.SchemaName, event.TableName); err != nil {
-		panic("get schema info err:" + err.Error())
+	if !event.tableFilter.Match(event.SchemaName, event.TableName) {
+		event.skipped = true
+		return nil
 	}
 
-	var columnCount, metaLen uint64
+	var columnCount uint64
 	var isNull bool
 
-	pack.readIntLengthOrNil(&columnCount, &isNull)
+	if err = pack.readIntLengthOrNil(&columnCount, &isNull); err != nil {
+		return err
+	}
 
+	if columnCount > uint64(pack.Len()) {
+		return EVENT_TOO_SHORT_ERR
+	}
 	columnTypeDef := pack.Next(int(columnCount))
 
-	// ignore len
-	pack.readIntLengthOrNil(&metaLen, &isNull)
-
-	// columnMetaDef, _ := pack.readStringLength()
-	// columnNullBitMap := pack.Bytes()
+	columnMetaDef, err := pack.readStringLength()
+	if err != nil {
+		return err
+	}
+	metaPack := newPackWithBuff(columnMetaDef)
 
 	event.Columns = make([]*Column, columnCount)
 
 	for i := 0; i < len(columnTypeDef); i++ {
-		if column, err := newColumn(pack, columnTypeDef[i] , event.schemaColumns[i]); err != nil {
-			panic(err)
+		if column, err := newColumn(metaPack, columnTypeDef[i]); err != nil {
+			return err
 		} else {
 			event.Columns[i] = column
 		}
 	}
+
+	columnNullBitMap, err := pack.next(int(columnCount+7) / 8)
+	if err != nil {
+		return err
+	}
+	for i, column := range event.Columns {
+		column.Nullable = isTrue(i, columnNullBitMap)
+	}
+
+	//the optional metadata of MySQL 8 saves the query of the columns
+	hasNames, err := event.readOptionalMetaData(pack)
+	if err != nil || hasNames {
+		return err
+	}
+
+	// get schema info, without the control connection the columns have no names
+
+	if table, ok := event.tableMap[event.TableId]; ok && table.Schema == event.SchemaName && table.Table == event.TableName {
+		event.schemaColumns = table.SchemaColumns
+	} else if event.ctrConn != nil {
+		if event.schemaColumns, err = event.ctrConn.getSchemaColumns(event.SchemaName, event.TableName); err != nil {
+			return fmt.Errorf("can not get columns of %s.%s: %s", event.SchemaName, event.TableName, err.Error())
+		}
+	}
+
+	for i, column := range event.Columns {
+		if i < len(event.schemaColumns) {
+			column.applySchema(event.schemaColumns[i])
+		}
+	}
+	return nil
 }
@@ -0,0 +1,81 @@
+package myreplication
+
+import (
+	"bytes"
+	"errors"
+)
+
+const (
+	_SEMI_SYNC_INDICATOR    = 0xef
+	_SEMI_SYNC_ACK_REQUIRED = 0x01
+)
+
+type (
+	semiSyncAck struct {
+	}
+)
+
+var (
+	SEMI_SYNC_NOT_SUPPORTED_ERR = errors.New("master has no semi-sync replication plugin")
+)
+
+func (ack *semiSyncAck) writeServer(position uint32, fileName string) *pack {
+	pack := newPack()
+	//magic byte
+	pack.WriteByte(_SEMI_SYNC_INDICATOR)
+	//position
+	pack.writeUInt64(uint64(position))
+	//filename
+	pack.Write([]byte(fileName))
+
+	return pack
+}
+
+// semiSync removes the semi-sync header from the event of the pack and
+// remembers the position the master waits an acknowledgement for
+func (ev *EventLog) semiSync(pack *pack) error {
+	if len(pack.buff) < 3 || pack.buff[1] != _SEMI_SYNC_INDICATOR {
+		return errors.New("event without semi-sync header")
+	}
+
+	ackRequired := pack.buff[2]&_SEMI_SYNC_ACK_REQUIRED != 0
+	pack.buff = append(pack.buff[:1], pack.buff[3:]...)
+	pack.Buffer = bytes.NewBuffer(pack.buff)
+
+	if ackRequired && len(pack.buff) >= 18 {
+		ev.ackLock.Lock()
+		ev.ackRequested = true
+		ev.ackFileName = string(ev.lastRotateFileName)
+		readUint32(pack.buff[14:18], &ev.ackPosition)
+		ev.ackLock.Unlock()
+	}
+
+	return nil
+}
+
+// GetAckRequest returns the last position the master waits an
+// acknowledgement for, ok is false when everything is acknowledged
+func (ev *EventLog) GetAckRequest() (fileName string, position uint32, ok bool) {
+	ev.ackLock.Lock()
+	defer ev.ackLock.Unlock()
+	return ev.ackFileName, ev.ackPosition, ev.ackRequested
+}
+
+// Ack tells a semi-sync master the events up to position are stored by the
+// replica, call it after the events are durably saved. It is safe to call
+// while another goroutine waits in GetEvent.
+func (ev *EventLog) Ack(fileName string, position uint32) error {
+	ev.ackLock.Lock()
+	defer ev.ackLock.Unlock()
+
+	ack := &semiSyncAck{}
+	if err := ev.mysqlConnection.packWriter.flush(ack.writeServer(position, fileName)); err != nil {
+		return err
+	}
+
+	if fileName == ev.ackFileName && position >= ev.ackPosition {
+		ev.ackRequested = false
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package myreplication
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSemiSyncAck(t *testing.T) {
+	ack := &semiSyncAck{}
+
+	result := ack.writeServer(uint32(1234), "mysql-bin.000003").packBytes()
+
+	expected := []byte{
+		0x19, 0x00, 0x00, 0x00,
+		0xef,
+		0xd2, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	expected = append(expected, []byte("mysql-bin.000003")...)
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatal(
+			"Incorrect semi-sync ack packet",
+			"expected", expected,
+			"got", result,
+		)
+	}
+}
+
+func TestSemiSyncReplica(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	master.appendQuery("BEGIN")
+	master.appendIntVar(1)
+	commit := master.appendXid(10)
+	master.semiSync = true
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+	cfg.SemiSync = true
+
+	connection := NewConnection()
+	if err := connection.Connect(cfg); err != nil {
+		t.Fatal("Connection fail", err)
+	}
+	defer connection.Close()
+
+	el, err := connection.StartBinlogDump(4, master.fileName, 2)
+	if err != nil {
+		t.Fatal("Start binlog dump fail", err)
+	}
+
+	found := false
+	for len(master.queries) > 0 {
+		if <-master.queries == "SET @rpl_semi_sync_slave = 1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("Semi-sync replica is not announced")
+	}
+
+	transaction, err := el.NextTransaction()
+	if err != nil || len(transaction.GetEvents()) != 1 {
+		t.Fatal("Incorrect transaction", transaction, err)
+	}
+
+	fileName, position, ok := el.GetAckRequest()
+	if !ok || fileName != master.fileName || position != commit {
+		t.Fatal(
+			"Incorrect ack request",
+			"expected", master.fileName, commit,
+			"got", fileName, position, ok,
+		)
+	}
+
+	if err = el.Ack(fileName, position); err != nil {
+		t.Fatal("Ack fail", err)
+	}
+
+	if _, _, ok = el.GetAckRequest(); ok {
+		t.Fatal("Ack request is not cleared")
+	}
+
+	expectedAck := fmt.Sprintf("%s:%d", master.fileName, commit)
+	select {
+	case ack := <-master.acks:
+		if ack != expectedAck {
+			t.Fatal(
+				"Incorrect ack",
+				"expected", expectedAck,
+				"got", ack,
+			)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Master got no ack")
+	}
+}
+
+func TestSemiSyncNotSupported(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+	cfg.SemiSync = true
+
+	connection := NewConnection()
+	if err := connection.Connect(cfg); err != nil {
+		t.Fatal("Connection fail", err)
+	}
+	defer connection.Close()
+
+	if _, err := connection.StartBinlogDump(4, master.fileName, 2); err != SEMI_SYNC_NOT_SUPPORTED_ERR {
+		t.Fatal(
+			"Incorrect error",
+			"expected", SEMI_SYNC_NOT_SUPPORTED_ERR,
+			"got", err,
+		)
+	}
+}
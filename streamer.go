@@ -0,0 +1,379 @@
+package myreplication
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	_DEFAULT_MIN_BACKOFF = 100 * time.Millisecond
+	_DEFAULT_MAX_BACKOFF = 30 * time.Second
+
+	//server errors a reconnect runs into again
+	_ER_DBACCESS_DENIED_ERROR             = 1044
+	_ER_ACCESS_DENIED_ERROR               = 1045
+	_ER_SPECIFIC_ACCESS_DENIED_ERROR      = 1227
+	_ER_MASTER_FATAL_ERROR_READING_BINLOG = 1236
+)
+
+type (
+	// Streamer reads the binlog like EventLog but reconnects when the connection
+	// drops and resumes from the end of the last fully received transaction.
+	// Events of a transaction cut by the disconnect are delivered only once.
+	Streamer struct {
+		config     *Config
+		connection *Connection
+		eventLog   *EventLog
+		tableMap   map[uint64]*Table
+
+		safeFileName string
+		safePosition uint32
+		gtidSet      *GTIDSet
+
+		deliveredFileName string
+		deliveredPosition uint32
+
+		transaction          streamedTransaction
+		deliveredTransaction streamedTransaction
+
+		skipChecksum    bool
+		heartbeatEvents bool
+		tableFilter     *TableFilter
+
+		checkpointStore CheckpointStore
+		committed       *Checkpoint
+
+		minBackoff time.Duration
+		maxBackoff time.Duration
+		maxRetries int
+	}
+
+	streamedTransaction struct {
+		sid    [16]byte
+		gno    int64
+		events int
+	}
+)
+
+func NewStreamer(config *Config, fileName string, position uint32) *Streamer {
+	return &Streamer{
+		config:       config,
+		tableMap:     map[uint64]*Table{},
+		safeFileName: fileName,
+		safePosition: position,
+		minBackoff:   _DEFAULT_MIN_BACKOFF,
+		maxBackoff:   _DEFAULT_MAX_BACKOFF,
+	}
+}
+
+// NewGTIDStreamer resumes by gtid set, so the stream can be moved to another
+// master of the topology without translating positions
+func NewGTIDStreamer(config *Config, gtidSet *GTIDSet) *Streamer {
+	streamer := NewStreamer(config, "", 4)
+	streamer.gtidSet = gtidSet.Clone()
+	return streamer
+}
+
+// SetBackoff sets the first and the largest pause between reconnects
+func (s *Streamer) SetBackoff(min, max time.Duration) {
+	s.minBackoff = min
+	s.maxBackoff = max
+}
+
+// SetMaxRetries limits consecutive failed reconnects, 0 retries forever
+func (s *Streamer) SetMaxRetries(retries int) {
+	s.maxRetries = retries
+}
+
+// SetChecksumVerification turns off the CRC32 check of the events
+func (s *Streamer) SetChecksumVerification(verify bool) {
+	s.skipChecksum = !verify
+}
+
+// SetHeartbeatEvents makes GetEvent return HeartBeatEvent
+func (s *Streamer) SetHeartbeatEvents(enabled bool) {
+	s.heartbeatEvents = enabled
+}
+
+// SetTableFilter skips the events of the tables filter does not match
+func (s *Streamer) SetTableFilter(filter *TableFilter) {
+	s.tableFilter = filter
+}
+
+// SetCheckpointStore makes the stream start from the checkpoint saved in
+// store instead of the position or gtid set given to the constructor, it must
+// be called before the first GetEvent
+func (s *Streamer) SetCheckpointStore(store CheckpointStore) error {
+	checkpoint, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	s.checkpointStore = store
+	s.committed = checkpoint
+	if checkpoint == nil {
+		return nil
+	}
+
+	if s.gtidSet != nil {
+		if checkpoint.GTIDSet != nil {
+			s.gtidSet = checkpoint.GTIDSet.Clone()
+		}
+		return nil
+	}
+
+	if checkpoint.Position.File != "" {
+		s.safeFileName, s.safePosition = checkpoint.Position.File, checkpoint.Position.Pos
+	}
+	return nil
+}
+
+// Commit saves the end of the last complete transaction returned by GetEvent
+// to the checkpoint store, it is called once the events are applied and from
+// the goroutine calling GetEvent
+func (s *Streamer) Commit() error {
+	if s.checkpointStore == nil {
+		return nil
+	}
+
+	checkpoint := &Checkpoint{
+		Position: Position{s.safeFileName, s.safePosition},
+	}
+	if s.gtidSet != nil {
+		checkpoint.GTIDSet = s.gtidSet.Clone()
+	}
+
+	if checkpoint.equal(s.committed) {
+		return nil
+	}
+
+	if err := s.checkpointStore.Save(checkpoint); err != nil {
+		return err
+	}
+
+	s.committed = checkpoint
+	return nil
+}
+
+// GetPosition returns the position the stream resumes from after a reconnect
+func (s *Streamer) GetPosition() (fileName string, position uint32) {
+	return s.safeFileName, s.safePosition
+}
+
+// GetGTIDSet returns the gtid set the stream resumes from, nil unless the
+// streamer is created by NewGTIDStreamer
+func (s *Streamer) GetGTIDSet() *GTIDSet {
+	return s.gtidSet
+}
+
+func (s *Streamer) GetEvent(ctx context.Context) (Event, error) {
+	retries := 0
+
+	for {
+		if s.eventLog == nil {
+			if err := s.connect(ctx); err != nil {
+				if !isRetryable(ctx, err) {
+					return nil, err
+				}
+				if err = s.wait(ctx, &retries, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		event, err := s.eventLog.GetEventContext(ctx)
+		s.safeFileName, s.safePosition = s.eventLog.safeFileName, s.eventLog.safePosition
+		if s.gtidSet != nil {
+			s.gtidSet = s.eventLog.gtidSet
+		}
+
+		if err != nil {
+			s.disconnect()
+			if !isRetryable(ctx, err) {
+				return nil, err
+			}
+			if err = s.wait(ctx, &retries, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		retries = 0
+
+		if _, ok := event.(*HeartBeatEvent); ok {
+			return event, nil
+		}
+
+		if s.gtidSet != nil {
+			if s.isTransactionDelivered() {
+				continue
+			}
+
+			s.deliveredTransaction = s.transaction
+			return event, nil
+		}
+
+		header := event.Header()
+		if s.isDelivered(header.LogFileName, header.NextPosition) {
+			continue
+		}
+
+		s.deliveredFileName, s.deliveredPosition = header.LogFileName, header.NextPosition
+		return event, nil
+	}
+}
+
+// GetAckRequest returns the position a semi-sync master waits an acknowledgement for
+func (s *Streamer) GetAckRequest() (fileName string, position uint32, ok bool) {
+	if s.eventLog == nil {
+		return "", 0, false
+	}
+	return s.eventLog.GetAckRequest()
+}
+
+// Ack acknowledges the events to a semi-sync master, unlike EventLog.Ack it
+// must be called from the goroutine calling GetEvent. The acknowledgements of
+// a lost connection are not needed, the master stops waiting for them.
+func (s *Streamer) Ack(fileName string, position uint32) error {
+	if s.eventLog == nil {
+		return nil
+	}
+	return s.eventLog.Ack(fileName, position)
+}
+
+func (s *Streamer) Close() {
+	s.disconnect()
+}
+
+// isDelivered is true for the events replayed between the resume position and
+// the last event returned before the connection was lost
+func (s *Streamer) isDelivered(fileName string, position uint32) bool {
+	return position != 0 && fileName == s.deliveredFileName && position <= s.deliveredPosition
+}
+
+// isTransactionDelivered counts the events of the current gtid transaction,
+// positions differ between masters so a replayed transaction is recognized
+// by its gtid and the number of events already returned from it
+func (s *Streamer) isTransactionDelivered() bool {
+	pending := s.eventLog.pendingGTID
+	if pending == nil {
+		s.transaction = streamedTransaction{}
+		return false
+	}
+
+	if pending.sid != s.transaction.sid || pending.gno != s.transaction.gno {
+		s.transaction = streamedTransaction{sid: pending.sid, gno: pending.gno}
+	}
+	s.transaction.events++
+
+	return s.transaction.sid == s.deliveredTransaction.sid &&
+		s.transaction.gno == s.deliveredTransaction.gno &&
+		s.transaction.events <= s.deliveredTransaction.events
+}
+
+func (s *Streamer) connect(ctx context.Context) error {
+	connection := NewConnection()
+	if err := connection.ConnectContext(ctx, s.config); err != nil {
+		return err
+	}
+
+	var eventLog *EventLog
+	var err error
+	if s.gtidSet != nil {
+		eventLog, err = connection.StartBinlogDumpGTIDContext(ctx, s.gtidSet, s.config.ServerId)
+	} else {
+		eventLog, err = connection.StartBinlogDumpContext(ctx, s.safePosition, s.safeFileName, s.config.ServerId)
+	}
+	if err != nil {
+		connection.Close()
+		return err
+	}
+
+	eventLog.tableMap = s.tableMap
+	eventLog.skipChecksum = s.skipChecksum
+	eventLog.heartbeatEvents = s.heartbeatEvents
+	eventLog.tableFilter = s.tableFilter
+	eventLog.safeFileName, eventLog.safePosition = s.safeFileName, s.safePosition
+
+	s.connection = connection
+	s.eventLog = eventLog
+	return nil
+}
+
+func (s *Streamer) disconnect() {
+	if s.connection != nil {
+		s.connection.Close()
+	}
+	s.connection = nil
+	s.eventLog = nil
+	s.transaction = streamedTransaction{}
+}
+
+func (s *Streamer) wait(ctx context.Context, retries *int, err error) error {
+	*retries++
+	if s.maxRetries > 0 && *retries > s.maxRetries {
+		return err
+	}
+
+	backoff := s.minBackoff
+	for i := 1; i < *retries && backoff < s.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > s.maxBackoff {
+		backoff = s.maxBackoff
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isRetryable is false for the errors a reconnect runs into again: the server
+// errors of a purged binlog, an invalid position or missing privileges, an
+// event that can not be decoded or verified at the resume position and the
+// failures of the configuration or the authentication
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch e := err.(type) {
+	case *errPacket:
+		switch e.code {
+		case _ER_DBACCESS_DENIED_ERROR, _ER_ACCESS_DENIED_ERROR,
+			_ER_SPECIFIC_ACCESS_DENIED_ERROR, _ER_MASTER_FATAL_ERROR_READING_BINLOG:
+			return false
+		}
+		return true
+	case *ParseError, *ChecksumMismatchError, *AuthError:
+		return false
+	}
+
+	switch err {
+	case TLS_NOT_SUPPORTED_ERR, SEMI_SYNC_NOT_SUPPORTED_ERR:
+		return false
+	}
+
+	var (
+		unknownNetwork     net.UnknownNetworkError
+		verification       *tls.CertificateVerificationError
+		unknownAuthority   x509.UnknownAuthorityError
+		invalidCertificate x509.CertificateInvalidError
+		hostname           x509.HostnameError
+	)
+	return !errors.As(err, &unknownNetwork) &&
+		!errors.As(err, &verification) &&
+		!errors.As(err, &unknownAuthority) &&
+		!errors.As(err, &invalidCertificate) &&
+		!errors.As(err, &hostname)
+}
@@ -0,0 +1,641 @@
+package myreplication
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	// standInMaster answers the commands used by StartBinlogDump and streams
+	// the events of one binlog file
+	standInMaster struct {
+		*standInServer
+		fileName string
+		events   [][]byte
+		// events sent by each dump before the connection is dropped, -1 sends all and waits
+		sendLimits []int
+		// server errors answered to the first dumps instead of the events
+		dumpErrors []uint16
+		// heartbeats sent after the events before the master goes silent
+		heartbeats int
+		dumps      chan *standInDump
+		queries    chan string
+		semiSync   bool
+		acks       chan string
+	}
+
+	standInDump struct {
+		fileName string
+		position uint32
+		gtidSet  string
+	}
+)
+
+func newStandInMaster(t *testing.T, fileName string) *standInMaster {
+	master := &standInMaster{
+		standInServer: newStandInServer(t, tls.Certificate{}, false),
+		fileName:      fileName,
+		dumps:         make(chan *standInDump, 16),
+		queries:       make(chan string, 16),
+		acks:          make(chan string, 16),
+	}
+	master.command = master.handleCommand
+
+	return master
+}
+
+func (m *standInMaster) nextPosition() uint32 {
+	position := uint32(4)
+	for _, event := range m.events {
+		var size uint32
+		readUint32(event[9:13], &size)
+		position += size
+	}
+	return position
+}
+
+func (m *standInMaster) appendEvent(eventType byte, body []byte) uint32 {
+	position := m.nextPosition()
+	m.events = append(m.events, standInEvent(eventType, position+uint32(19+len(body)), body))
+	return position + uint32(19+len(body))
+}
+
+func (m *standInMaster) appendQuery(query string) uint32 {
+	return m.appendEvent(_QUERY_EVENT, standInQueryBody("test", query))
+}
+
+func (m *standInMaster) appendIntVar(value uint64) uint32 {
+	return m.appendEvent(_INTVAR_EVENT, standInIntVarBody(value))
+}
+
+func (m *standInMaster) appendXid(xid uint64) uint32 {
+	return m.appendEvent(_XID_EVENT, standInXidBody(xid))
+}
+
+func (m *standInMaster) appendGTID(sid string, gno int64) uint32 {
+	return m.appendEvent(_GTID_EVENT, standInGtidBody(sid, gno))
+}
+
+func standInEvent(eventType byte, nextPosition uint32, body []byte) []byte {
+	header := make([]byte, 19)
+	writeUInt32(header[0:4], uint32(time.Now().Unix()))
+	header[4] = eventType
+	writeUInt32(header[5:9], 1)
+	writeUInt32(header[9:13], uint32(19+len(body)))
+	writeUInt32(header[13:17], nextPosition)
+	return append(header, body...)
+}
+
+func standInQueryBody(schema, query string) []byte {
+	body := make([]byte, 13)
+	body[8] = byte(len(schema))
+	body = append(body, []byte(schema)...)
+	body = append(body, 0)
+	return append(body, []byte(query)...)
+}
+
+func standInIntVarBody(value uint64) []byte {
+	body := make([]byte, 9)
+	body[0] = INSERT_ID_EVENT
+	writeUInt64(body[1:], value)
+	return body
+}
+
+func standInXidBody(xid uint64) []byte {
+	body := make([]byte, 8)
+	writeUInt64(body, xid)
+	return body
+}
+
+func standInGtidBody(sid string, gno int64) []byte {
+	parsedSid, _ := parseSid(sid)
+	body := make([]byte, 25)
+	body[0] = 1
+	copy(body[1:17], parsedSid[:])
+	writeUInt64(body[17:], uint64(gno))
+	return body
+}
+
+func standInRotateBody(fileName string, position uint64) []byte {
+	body := make([]byte, 8)
+	writeUInt64(body, position)
+	return append(body, []byte(fileName)...)
+}
+
+func standInFormatDescriptionBody() []byte {
+	//the last 5 bytes are the checksum algorithm, off, and the unused checksum value
+	body := make([]byte, 2+50+4+1+38+5)
+	writeUInt16(body[0:2], 4)
+	copy(body[2:52], "5.6.10-stand-in")
+	body[56] = 19
+	return body
+}
+
+func (s *standInSession) resultSet(columns []string, rows [][]string) error {
+	if err := s.write(byte(len(columns))); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		definition := newPack()
+		for _, field := range []string{"def", "", "", "", column, column} {
+			definition.writeStringLength(field)
+		}
+		definition.WriteByte(0x0c)
+		definition.writeUInt16(0x21)
+		definition.writeUInt32(255)
+		definition.WriteByte(MYSQL_TYPE_VAR_STRING)
+		definition.writeUInt16(0)
+		definition.WriteByte(0)
+		definition.writeUInt16(0)
+		if err := s.write(definition.Bytes()[4:]...); err != nil {
+			return err
+		}
+	}
+
+	if err := s.write(_MYSQL_EOF, 0x00, 0x00, 0x02, 0x00); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		data := newPack()
+		for _, field := range row {
+			data.writeStringLength(field)
+		}
+		if err := s.write(data.Bytes()[4:]...); err != nil {
+			return err
+		}
+	}
+
+	return s.write(_MYSQL_EOF, 0x00, 0x00, 0x02, 0x00)
+}
+
+// event sends a binlog event, a semi-sync master asks to acknowledge the Xid events
+func (m *standInMaster) event(session *standInSession, data []byte) error {
+	if !m.semiSync {
+		return session.write(append([]byte{_MYSQL_OK}, data...)...)
+	}
+
+	var flag byte
+	if data[4] == _XID_EVENT {
+		flag = _SEMI_SYNC_ACK_REQUIRED
+	}
+	return session.write(append([]byte{_MYSQL_OK, _SEMI_SYNC_INDICATOR, flag}, data...)...)
+}
+
+func (m *standInMaster) handleCommand(session *standInSession, command []byte) error {
+	switch command[0] {
+	case _COM_QUERY:
+		query := string(command[1:])
+		select {
+		case m.queries <- query:
+		default:
+		}
+		if strings.Contains(query, "rpl_semi_sync_master_enabled") && m.semiSync {
+			return session.resultSet([]string{"Variable_name", "Value"}, [][]string{{"rpl_semi_sync_master_enabled", "ON"}})
+		}
+		if strings.HasPrefix(strings.ToUpper(query), "SHOW") {
+			return session.resultSet([]string{"Variable_name", "Value"}, nil)
+		}
+		return session.ok()
+	case _COM_BINLOG_DUMP:
+		var position uint32
+		readUint32(command[1:5], &position)
+		return m.dump(session, string(command[11:]), position, nil)
+	case _COM_BINLOG_DUMP_GTID:
+		var nameLength, dataLength uint32
+		readUint32(command[7:11], &nameLength)
+		readUint32(command[19+nameLength:23+nameLength], &dataLength)
+		gtidSet, err := decodeGTIDSet(command[23+nameLength : 23+nameLength+dataLength])
+		if err != nil {
+			return err
+		}
+		return m.dump(session, m.fileName, 4, gtidSet)
+	}
+
+	return session.ok()
+}
+
+// dump streams the events from position, with a gtid set it streams the
+// transactions missing from the set
+func (m *standInMaster) dump(session *standInSession, fileName string, position uint32, gtidSet *GTIDSet) error {
+	dump := &standInDump{fileName: fileName, position: position}
+	if gtidSet != nil {
+		dump.gtidSet = gtidSet.String()
+	}
+	m.dumps <- dump
+
+	if len(m.dumpErrors) > 0 {
+		errPack := []byte{_MYSQL_ERR, 0x00, 0x00}
+		writeUInt16(errPack[1:3], m.dumpErrors[0])
+		m.dumpErrors = m.dumpErrors[1:]
+		session.write(append(errPack, []byte("#08004stand-in error")...)...)
+		return net.ErrClosed
+	}
+
+	limit := -1
+	if len(m.sendLimits) > 0 {
+		limit = m.sendLimits[0]
+		m.sendLimits = m.sendLimits[1:]
+	}
+
+	if err := m.event(session, standInEvent(_ROTATE_EVENT, 0, standInRotateBody(fileName, uint64(position)))); err != nil {
+		return err
+	}
+
+	if err := m.event(session, standInEvent(_FORMAT_DESCRIPTION_EVENT, 0, standInFormatDescriptionBody())); err != nil {
+		return err
+	}
+
+	eventPosition := uint32(4)
+	executed := false
+	for _, event := range m.events {
+		var size uint32
+		readUint32(event[9:13], &size)
+		start := eventPosition
+		eventPosition += size
+
+		if gtidSet != nil && event[4] == _GTID_EVENT {
+			var gno uint64
+			readUint64(event[36:44], &gno)
+			var sid [16]byte
+			copy(sid[:], event[20:36])
+			executed = gtidSet.containsInterval(sid, gtidInterval{int64(gno), int64(gno) + 1})
+		}
+
+		if start < position || executed {
+			continue
+		}
+
+		if limit == 0 {
+			return net.ErrClosed
+		}
+		limit--
+
+		if err := m.event(session, event); err != nil {
+			return err
+		}
+	}
+
+	if limit >= 0 {
+		return net.ErrClosed
+	}
+
+	for i := 0; i < m.heartbeats; i++ {
+		if err := m.event(session, standInEvent(_HEARTBEAT_EVENT, eventPosition, []byte(fileName))); err != nil {
+			return err
+		}
+	}
+
+	//wait for the client to go away, only semi-sync acknowledgements come in
+	for {
+		data, err := session.read()
+		if err != nil {
+			return err
+		}
+
+		if data[0] == _SEMI_SYNC_INDICATOR {
+			var position uint64
+			readUint64(data[1:9], &position)
+			m.acks <- fmt.Sprintf("%s:%d", data[9:], position)
+		}
+	}
+}
+
+func TestStreamerResume(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	master.appendQuery("BEGIN")
+	master.appendIntVar(1)
+	firstCommit := master.appendXid(10)
+	master.appendQuery("BEGIN")
+	master.appendIntVar(2)
+	master.appendIntVar(3)
+	master.appendXid(11)
+	master.appendQuery("CREATE TABLE t (id int)")
+
+	//both first connections drop inside the second transaction
+	master.sendLimits = []int{5, 3}
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+	cfg.ServerId = 2
+
+	streamer := NewStreamer(cfg, master.fileName, 4)
+	streamer.SetBackoff(time.Millisecond, 10*time.Millisecond)
+	defer streamer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	expected := []string{"BEGIN", "1", "BEGIN", "2", "3", "CREATE TABLE t (id int)"}
+
+	for i, expectedEvent := range expected {
+		event, err := streamer.GetEvent(ctx)
+		if err != nil {
+			t.Fatal("Get event fail at", i, err)
+		}
+
+		var got string
+		switch e := event.(type) {
+		case *QueryEvent:
+			got = e.GetQuery()
+		case *IntVarEvent:
+			got = string('0' + byte(e.GetValue()))
+		}
+
+		if got != expectedEvent {
+			t.Fatal(
+				"Incorrect event at", i,
+				"expected", expectedEvent,
+				"got", got,
+			)
+		}
+	}
+
+	expectedDumps := []*standInDump{
+		&standInDump{master.fileName, 4, ""},
+		&standInDump{master.fileName, firstCommit, ""},
+		&standInDump{master.fileName, firstCommit, ""},
+	}
+
+	for i, expectedDump := range expectedDumps {
+		dump := <-master.dumps
+		if *dump != *expectedDump {
+			t.Fatal(
+				"Incorrect dump position at", i,
+				"expected", *expectedDump,
+				"got", *dump,
+			)
+		}
+	}
+
+	fileName, position := streamer.GetPosition()
+	if fileName != master.fileName || position != master.nextPosition() {
+		t.Fatal(
+			"Incorrect resume position",
+			"expected", master.nextPosition(),
+			"got", fileName, position,
+		)
+	}
+}
+
+func TestGTIDStreamerResume(t *testing.T) {
+	sid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	master.appendGTID(sid, 1)
+	master.appendQuery("CREATE TABLE t (id int)")
+	master.appendGTID(sid, 2)
+	master.appendQuery("BEGIN")
+	master.appendIntVar(1)
+	master.appendXid(10)
+	master.appendGTID(sid, 3)
+	master.appendQuery("BEGIN")
+	master.appendIntVar(2)
+	master.appendIntVar(3)
+	master.appendXid(11)
+
+	//drops inside the transaction 3
+	master.sendLimits = []int{7}
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+
+	started, _ := ParseGTIDSet(sid + ":1")
+	streamer := NewGTIDStreamer(cfg, started)
+	streamer.SetBackoff(time.Millisecond, 10*time.Millisecond)
+	defer streamer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	expected := []string{"gtid 2", "BEGIN", "1", "gtid 3", "BEGIN", "2", "3"}
+
+	for i, expectedEvent := range expected {
+		event, err := streamer.GetEvent(ctx)
+		if err != nil {
+			t.Fatal("Get event fail at", i, err)
+		}
+
+		var got string
+		switch e := event.(type) {
+		case *QueryEvent:
+			got = e.GetQuery()
+		case *IntVarEvent:
+			got = string('0' + byte(e.GetValue()))
+		case *GtidEvent:
+			got = "gtid " + string('0'+byte(e.GetGNO()))
+		}
+
+		if got != expectedEvent {
+			t.Fatal(
+				"Incorrect event at", i,
+				"expected", expectedEvent,
+				"got", got,
+			)
+		}
+	}
+
+	expectedDumps := []string{sid + ":1", sid + ":1-2"}
+
+	for i, expectedDump := range expectedDumps {
+		if dump := <-master.dumps; dump.gtidSet != expectedDump {
+			t.Fatal(
+				"Incorrect dump gtid set at", i,
+				"expected", expectedDump,
+				"got", dump.gtidSet,
+			)
+		}
+	}
+
+	//the Xid of the transaction 3 is read with the next event
+	if streamer.GetGTIDSet().String() != sid+":1-2" || started.String() != sid+":1" {
+		t.Fatal(
+			"Incorrect resume gtid set",
+			"expected", sid+":1-2",
+			"got", streamer.GetGTIDSet().String(),
+		)
+	}
+}
+
+func TestStreamerServerError(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	master.command = func(session *standInSession, command []byte) error {
+		if command[0] == _COM_REGISTER_SLAVE {
+			return session.write(append([]byte{_MYSQL_ERR, 0x15, 0x04}, []byte("#28000Access denied")...)...)
+		}
+		return master.handleCommand(session, command)
+	}
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+
+	streamer := NewStreamer(cfg, master.fileName, 4)
+	streamer.SetBackoff(time.Millisecond, time.Millisecond)
+	defer streamer.Close()
+
+	if _, err := streamer.GetEvent(context.Background()); err == nil {
+		t.Fatal("Expected server error")
+	} else if _, ok := err.(*errPacket); !ok {
+		t.Fatal(
+			"Incorrect error",
+			"expected", "*errPacket",
+			"got", err,
+		)
+	}
+}
+
+func TestStreamerParseError(t *testing.T) {
+	master := newStandInMaster(t, "mysql-bin.000001")
+	defer master.close()
+
+	master.appendQuery("BEGIN")
+	//an INTVAR_EVENT without its value fails at every reconnect
+	master.appendEvent(_INTVAR_EVENT, []byte{INSERT_ID_EVENT, 0x01})
+	master.start()
+
+	cfg := NewConfig()
+	cfg.Addr = master.listener.Addr().String()
+
+	streamer := NewStreamer(cfg, master.fileName, 4)
+	streamer.SetBackoff(time.Millisecond, time.Millisecond)
+	defer streamer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := streamer.GetEvent(ctx); err != nil {
+		t.Fatal("Get event fail", err)
+	}
+
+	_, err := streamer.GetEvent(ctx)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatal(
+			"Incorrect error",
+			"expected", "*ParseError",
+			"got", err,
+		)
+	}
+
+	if len(master.dumps) != 1 {
+		t.Fatal("Incorrect dump count", "expected", 1, "got", len(master.dumps))
+	}
+}
+
+func TestStreamerDumpError(t *testing.T) {
+	type serverErrorTestCase struct {
+		code          uint16
+		expectedDumps int
+		expectedError bool
+	}
+
+	//too many connections goes away, a purged binlog stays
+	testCases := []*serverErrorTestCase{
+		&serverErrorTestCase{1040, 2, false},
+		&serverErrorTestCase{1236, 1, true},
+	}
+
+	for i, testCase := range testCases {
+		master := newStandInMaster(t, "mysql-bin.000001")
+		master.appendQuery("CREATE TABLE t (id int)")
+		master.dumpErrors = []uint16{testCase.code}
+		master.start()
+
+		cfg := NewConfig()
+		cfg.Addr = master.listener.Addr().String()
+
+		streamer := NewStreamer(cfg, master.fileName, 4)
+		streamer.SetBackoff(time.Millisecond, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		event, err := streamer.GetEvent(ctx)
+		cancel()
+		streamer.Close()
+		master.close()
+
+		if testCase.expectedError {
+			if packet, ok := err.(*errPacket); !ok || packet.code != testCase.code {
+				t.Fatal(
+					"Incorrect error at test", i,
+					"expected", testCase.code,
+					"got", event, err,
+				)
+			}
+		} else if query, ok := event.(*QueryEvent); err != nil || !ok || query.GetQuery() != "CREATE TABLE t (id int)" {
+			t.Fatal(
+				"Incorrect event at test", i,
+				"expected", "CREATE TABLE t (id int)",
+				"got", event, err,
+			)
+		}
+
+		if len(master.dumps) != testCase.expectedDumps {
+			t.Fatal(
+				"Incorrect dump count at test", i,
+				"expected", testCase.expectedDumps,
+				"got", len(master.dumps),
+			)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	type (
+		testCase struct {
+			err       error
+			retryable bool
+		}
+	)
+
+	testCases := []*testCase{
+		&testCase{net.ErrClosed, true},
+		&testCase{HEARTBEAT_LOST_ERR, true},
+		&testCase{&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, true},
+		&testCase{&errPacket{code: 1040}, true},
+		&testCase{&errPacket{code: 1053}, true},
+		&testCase{&errPacket{code: 1205}, true},
+		&testCase{&errPacket{code: 1044}, false},
+		&testCase{&errPacket{code: 1045}, false},
+		&testCase{&errPacket{code: 1227}, false},
+		&testCase{&errPacket{code: 1236}, false},
+		&testCase{&ParseError{Err: EVENT_TOO_SHORT_ERR}, false},
+		&testCase{&ChecksumMismatchError{}, false},
+		&testCase{&AuthError{fmt.Errorf("unsupported auth plugin dialog")}, false},
+		&testCase{TLS_NOT_SUPPORTED_ERR, false},
+		&testCase{SEMI_SYNC_NOT_SUPPORTED_ERR, false},
+		&testCase{&net.OpError{Op: "dial", Err: net.UnknownNetworkError("udp")}, false},
+		&testCase{&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, false},
+		&testCase{x509.HostnameError{}, false},
+	}
+
+	for i, testCase := range testCases {
+		if retryable := isRetryable(context.Background(), testCase.err); retryable != testCase.retryable {
+			t.Fatal(
+				"Incorrect retryable at test", i,
+				"expected", testCase.retryable,
+				"got", retryable,
+			)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if isRetryable(ctx, net.ErrClosed) {
+		t.Fatal("Incorrect retryable", "expected", false, "got", true)
+	}
+}
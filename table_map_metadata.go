@@ -0,0 +1,230 @@
+package myreplication
+
+import (
+	"errors"
+)
+
+var (
+	// charsets of the usual collations besides the default ones of
+	// charsetCollations
+	collationCharsets = map[uint64]string{
+		47:  "latin1",
+		65:  "ascii",
+		83:  "utf8",
+		192: "utf8",
+		46:  "utf8mb4",
+		224: "utf8mb4",
+		255: "utf8mb4",
+		87:  "gbk",
+		84:  "big5",
+		86:  "gb2312",
+	}
+)
+
+// readOptionalMetaData reads the optional metadata fields MySQL 8 writes after
+// the null bitmap, true when they name the columns
+func (event *TableMapEvent) readOptionalMetaData(pack *pack) (bool, error) {
+	hasNames := false
+
+	for pack.Len() > 0 {
+		fieldType, _ := pack.ReadByte()
+		value, err := pack.readStringLength()
+		if err != nil {
+			return false, err
+		}
+
+		field := newPackWithBuff(value)
+
+		switch fieldType {
+		case _TABLE_MAP_SIGNEDNESS:
+			event.readSignedness(value)
+		case _TABLE_MAP_DEFAULT_CHARSET:
+			err = event.readDefaultCharset(field, isCharacterColumn)
+		case _TABLE_MAP_COLUMN_CHARSET:
+			err = event.readColumnCharset(field, isCharacterColumn)
+		case _TABLE_MAP_ENUM_AND_SET_DEFAULT_CHARSET:
+			err = event.readDefaultCharset(field, isEnumOrSetColumn)
+		case _TABLE_MAP_ENUM_AND_SET_COLUMN_CHARSET:
+			err = event.readColumnCharset(field, isEnumOrSetColumn)
+		case _TABLE_MAP_COLUMN_NAME:
+			for _, column := range event.Columns {
+				var name []byte
+				if name, err = field.readStringLength(); err != nil {
+					break
+				}
+				column.Name = string(name)
+			}
+			hasNames = true
+		case _TABLE_MAP_SET_STR_VALUE:
+			err = event.readStrValues(field, MYSQL_TYPE_SET)
+		case _TABLE_MAP_ENUM_STR_VALUE:
+			err = event.readStrValues(field, MYSQL_TYPE_ENUM)
+		case _TABLE_MAP_GEOMETRY_TYPE:
+			for _, column := range event.Columns {
+				if column.Type != MYSQL_TYPE_GEOMETRY {
+					continue
+				}
+				var geometryType uint64
+				if geometryType, err = field.readIntLength(); err != nil {
+					break
+				}
+				column.GeometryType = uint8(geometryType)
+			}
+		case _TABLE_MAP_SIMPLE_PRIMARY_KEY, _TABLE_MAP_PRIMARY_KEY_WITH_PREFIX:
+			for field.Len() > 0 {
+				var index uint64
+				if index, err = field.readIntLength(); err != nil {
+					break
+				}
+				if fieldType == _TABLE_MAP_PRIMARY_KEY_WITH_PREFIX {
+					if _, err = field.readIntLength(); err != nil {
+						break
+					}
+				}
+				if index >= uint64(len(event.Columns)) {
+					return false, errors.New("incorrect TABLE_MAP_EVENT column index")
+				}
+				//a prefix length of the key is not kept
+				event.Columns[index].IsPrimary = true
+			}
+		}
+		//the fields of newer servers are skipped
+
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return hasNames, nil
+}
+
+// readSignedness reads the bitmap of the numeric columns, most significant bit
+// first, a set bit is an unsigned column
+func (event *TableMapEvent) readSignedness(bitmap []byte) {
+	i := 0
+	for _, column := range event.Columns {
+		if !isNumericColumn(column) {
+			continue
+		}
+		if i/8 < len(bitmap) && (bitmap[i/8]>>uint(7-i%8))&1 == 1 {
+			column.Unsigned = true
+		}
+		i++
+	}
+}
+
+// readDefaultCharset reads the default collation of the columns selected by is,
+// followed by the pairs of index among them and collation of the others
+func (event *TableMapEvent) readDefaultCharset(field *pack, is func(*Column) bool) error {
+	defaultCollation, err := field.readIntLength()
+	if err != nil {
+		return err
+	}
+
+	var columns []*Column
+	for _, column := range event.Columns {
+		if is(column) {
+			column.setCollation(defaultCollation)
+			columns = append(columns, column)
+		}
+	}
+
+	for field.Len() > 0 {
+		index, err := field.readIntLength()
+		if err != nil {
+			return err
+		}
+		collation, err := field.readIntLength()
+		if err != nil {
+			return err
+		}
+		if index >= uint64(len(columns)) {
+			return errors.New("incorrect TABLE_MAP_EVENT column index")
+		}
+		columns[index].setCollation(collation)
+	}
+	return nil
+}
+
+// readColumnCharset reads the collation of every column selected by is
+func (event *TableMapEvent) readColumnCharset(field *pack, is func(*Column) bool) error {
+	for _, column := range event.Columns {
+		if !is(column) {
+			continue
+		}
+		collation, err := field.readIntLength()
+		if err != nil {
+			return err
+		}
+		column.setCollation(collation)
+	}
+	return nil
+}
+
+// readStrValues reads the labels of the ENUM or SET columns
+func (event *TableMapEvent) readStrValues(field *pack, columnType byte) error {
+	for _, column := range event.Columns {
+		if column.Type != columnType {
+			continue
+		}
+
+		count, err := field.readIntLength()
+		if err != nil {
+			return err
+		}
+		if count > uint64(field.Len()) {
+			return EVENT_TOO_SHORT_ERR
+		}
+
+		values := make([]string, count)
+		for i := range values {
+			value, err := field.readStringLength()
+			if err != nil {
+				return err
+			}
+			values[i] = string(value)
+		}
+
+		if columnType == MYSQL_TYPE_ENUM {
+			column.EnumValues = values
+		} else {
+			column.SetValues = values
+		}
+	}
+	return nil
+}
+
+func (c *Column) setCollation(collation uint64) {
+	c.CollationId = collation
+	c.Charset = collationCharset(collation)
+}
+
+func collationCharset(collation uint64) string {
+	for charset, id := range charsetCollations {
+		if uint64(id) == collation {
+			return charset
+		}
+	}
+	return collationCharsets[collation]
+}
+
+func isNumericColumn(column *Column) bool {
+	switch column.Type {
+	case MYSQL_TYPE_TINY, MYSQL_TYPE_SHORT, MYSQL_TYPE_INT24, MYSQL_TYPE_LONG, MYSQL_TYPE_LONGLONG,
+		MYSQL_TYPE_NEWDECIMAL, MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE:
+		return true
+	}
+	return false
+}
+
+func isCharacterColumn(column *Column) bool {
+	switch column.Type {
+	case MYSQL_TYPE_STRING, MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_VARCHAR, MYSQL_TYPE_BLOB:
+		return true
+	}
+	return false
+}
+
+func isEnumOrSetColumn(column *Column) bool {
+	return column.Type == MYSQL_TYPE_ENUM || column.Type == MYSQL_TYPE_SET
+}
@@ -0,0 +1,118 @@
+package myreplication
+
+import (
+	"reflect"
+	"testing"
+)
+
+func standInItemsTableMapBody(optionalMetaData ...byte) []byte {
+	body := []byte{
+		0x2a, 0x00, 0x00, 0x00, 0x00, 0x00, // table id
+		0x01, 0x00, // flags
+		0x04, 's', 'h', 'o', 'p', 0x00,
+		0x05, 'i', 't', 'e', 'm', 's', 0x00,
+		//id int, name varchar, status enum, tags set, score tinyint, shape geometry
+		0x06, 0x03, 0x0f, 0xfe, 0xfe, 0x01, 0xff,
+		0x07, 0x50, 0x00, 0xf7, 0x01, 0xf8, 0x01, 0x04,
+		//name and score are nullable
+		0x12,
+	}
+	return append(body, optionalMetaData...)
+}
+
+func TestTableMapEventOptionalMetaData(t *testing.T) {
+	body := standInItemsTableMapBody(
+		//signedness, id is unsigned
+		_TABLE_MAP_SIGNEDNESS, 0x01, 0x80,
+		//latin1 by default, utf8mb4_0900_ai_ci for name
+		_TABLE_MAP_DEFAULT_CHARSET, 0x05, 0x08, 0x00, 0xfc, 0xff, 0x00,
+		_TABLE_MAP_COLUMN_NAME, 0x20,
+		0x02, 'i', 'd',
+		0x04, 'n', 'a', 'm', 'e',
+		0x06, 's', 't', 'a', 't', 'u', 's',
+		0x04, 't', 'a', 'g', 's',
+		0x05, 's', 'c', 'o', 'r', 'e',
+		0x05, 's', 'h', 'a', 'p', 'e',
+		_TABLE_MAP_SET_STR_VALUE, 0x07, 0x03, 0x01, 'x', 0x01, 'y', 0x01, 'z',
+		_TABLE_MAP_ENUM_STR_VALUE, 0x05, 0x02, 0x01, 'a', 0x01, 'b',
+		_TABLE_MAP_GEOMETRY_TYPE, 0x01, 0x01,
+		_TABLE_MAP_SIMPLE_PRIMARY_KEY, 0x01, 0x00,
+		_TABLE_MAP_ENUM_AND_SET_DEFAULT_CHARSET, 0x01, 0x2d,
+		//a field of a newer server
+		0x0c, 0x01, 0x00,
+	)
+
+	//the cached columns are not used when the event names the columns
+	table := &TableMapEvent{
+		eventLogHeader: &eventLogHeader{EventType: _TABLE_MAP_EVENT},
+		tableMap: map[uint64]*Table{
+			42: &Table{Schema: "shop", Table: "items", SchemaColumns: []*SchemaColumn{&SchemaColumn{COLUMN_NAME: "wrong"}}},
+		},
+	}
+
+	if err := table.read(newPackWithBuff(body)); err != nil {
+		t.Fatal("Read table map fail", err)
+	}
+
+	expected := []*Column{
+		&Column{Type: MYSQL_TYPE_LONG, Name: "id", Unsigned: true, IsPrimary: true},
+		&Column{Type: MYSQL_TYPE_VARCHAR, Name: "name", Nullable: true, MaxLen: 80, CollationId: 255, Charset: "utf8mb4"},
+		&Column{Type: MYSQL_TYPE_ENUM, Name: "status", Size: 1, CollationId: 45, Charset: "utf8mb4", EnumValues: []string{"a", "b"}},
+		&Column{Type: MYSQL_TYPE_SET, Name: "tags", Size: 1, CollationId: 45, Charset: "utf8mb4", SetValues: []string{"x", "y", "z"}},
+		&Column{Type: MYSQL_TYPE_TINY, Name: "score", Nullable: true},
+		&Column{Type: MYSQL_TYPE_GEOMETRY, Name: "shape", LenSize: 4, GeometryType: 1},
+	}
+
+	if len(table.Columns) != len(expected) {
+		t.Fatal("Incorrect column count", "expected", len(expected), "got", len(table.Columns))
+	}
+
+	for i, column := range table.Columns {
+		if !reflect.DeepEqual(column, expected[i]) {
+			t.Fatal(
+				"Incorrect column at", i,
+				"expected", *expected[i],
+				"got", *column,
+			)
+		}
+	}
+
+	if table.schemaColumns != nil {
+		t.Fatal("Incorrect schema columns", "expected", nil, "got", table.schemaColumns)
+	}
+}
+
+func TestTableMapEventSchemaColumns(t *testing.T) {
+	//without optional metadata the cached information_schema columns apply
+	table := &TableMapEvent{
+		eventLogHeader: &eventLogHeader{EventType: _TABLE_MAP_EVENT},
+		tableMap: map[uint64]*Table{
+			42: &Table{Schema: "shop", Table: "items", SchemaColumns: []*SchemaColumn{
+				&SchemaColumn{COLUMN_NAME: "id", COLUMN_TYPE: "int(10) unsigned", COLUMN_KEY: "PRI"},
+				&SchemaColumn{COLUMN_NAME: "name", COLUMN_TYPE: "varchar(20)", CHARACTER_SET_NAME: []byte("utf8mb4")},
+				&SchemaColumn{COLUMN_NAME: "status", COLUMN_TYPE: "enum('a','b')"},
+			}},
+		},
+	}
+
+	if err := table.read(newPackWithBuff(standInItemsTableMapBody())); err != nil {
+		t.Fatal("Read table map fail", err)
+	}
+
+	id, name, status := table.Columns[0], table.Columns[1], table.Columns[2]
+	if id.Name != "id" || !id.Unsigned || !id.IsPrimary {
+		t.Fatal("Incorrect column", "expected", "unsigned primary id", "got", *id)
+	}
+
+	if name.Name != "name" || name.Unsigned || name.Charset != "utf8mb4" {
+		t.Fatal("Incorrect column", "expected", "utf8mb4 name", "got", *name)
+	}
+
+	if status.Type != MYSQL_TYPE_ENUM || !reflect.DeepEqual(status.EnumValues, []string{"a", "b"}) {
+		t.Fatal("Incorrect column", "expected", "enum status", "got", *status)
+	}
+
+	if table.Columns[4].Name != "" {
+		t.Fatal("Incorrect column", "expected", "no name", "got", *table.Columns[4])
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
@@ -48,7 +49,8 @@ func TestRowReplication(t *testing.T) {
 	if err != nil {
 		t.Fatal("Cant start bin log: ", err.Error())
 	}
-	events := el.GetEventChan()
+	stream := el.Stream(context.Background(), 16)
+	events := stream.Events()
 
 	go func() {
 		con, err := sql.Open("mysql", fmt.Sprintf(
@@ -122,9 +124,9 @@ func TestRowReplication(t *testing.T) {
 		}
 
 		tests := []*columnTest{
-			&columnTest{0, myreplication.MYSQL_TYPE_LONG, uint32(maxId), false},
+			&columnTest{0, myreplication.MYSQL_TYPE_LONG, int32(maxId), false},
 			&columnTest{1, myreplication.MYSQL_TYPE_VARCHAR, "Hello!", false},
-			&columnTest{2, myreplication.MYSQL_TYPE_LONG, uint32(10), false},
+			&columnTest{2, myreplication.MYSQL_TYPE_LONG, int32(10), false},
 		}
 
 		for i, column := range columns {
@@ -267,9 +269,9 @@ func TestRowReplication(t *testing.T) {
 		}
 
 		tests = []*columnTest{
-			&columnTest{0, myreplication.MYSQL_TYPE_LONG, uint32(maxId), false},
+			&columnTest{0, myreplication.MYSQL_TYPE_LONG, int32(maxId), false},
 			&columnTest{1, myreplication.MYSQL_TYPE_VARCHAR, "World!", false},
-			&columnTest{2, myreplication.MYSQL_TYPE_LONG, uint32(10), false},
+			&columnTest{2, myreplication.MYSQL_TYPE_LONG, int32(10), false},
 		}
 
 		for i, column := range columns {
@@ -394,9 +396,9 @@ func TestRowReplication(t *testing.T) {
 		os.Exit(0)
 	}()
 
-	err = el.Start()
+	<-stream.Done()
 
-	if err != nil {
-		t.Fatal("Start error", err)
+	if err = stream.Err(); err != nil {
+		t.Fatal("Stream error", err)
 	}
 }
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
@@ -38,7 +39,8 @@ func TestStatementReplication(t *testing.T) {
 	if err != nil {
 		t.Fatal("Cant start bin log: ", err.Error())
 	}
-	events := el.GetEventChan()
+	stream := el.Stream(context.Background(), 16)
+	events := stream.Events()
 
 	go func() {
 		con, err := sql.Open("mysql", fmt.Sprintf(
@@ -85,9 +87,9 @@ func TestStatementReplication(t *testing.T) {
 		os.Exit(0)
 	}()
 
-	err = el.Start()
+	<-stream.Done()
 
-	if err != nil {
-		t.Fatal("Start error", err)
+	if err = stream.Err(); err != nil {
+		t.Fatal("Stream error", err)
 	}
 }
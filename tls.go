@@ -0,0 +1,79 @@
+package myreplication
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+type (
+	TLSMode int
+)
+
+const (
+	TLS_MODE_DISABLED TLSMode = iota
+	TLS_MODE_PREFERRED
+	TLS_MODE_REQUIRED
+	TLS_MODE_VERIFY_CA
+	TLS_MODE_VERIFY_IDENTITY
+)
+
+var (
+	TLS_NOT_SUPPORTED_ERR = errors.New("server does not support TLS")
+)
+
+// clientTLSConfig builds the config used for the handshake upgrade.
+// PREFERRED and REQUIRED only encrypt, VERIFY_CA checks the chain
+// without the host name and VERIFY_IDENTITY does the full check, like the mysql client
+func clientTLSConfig(mode TLSMode, config *tls.Config, host string) *tls.Config {
+	var cfg *tls.Config
+	if config != nil {
+		cfg = config.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+
+	switch mode {
+	case TLS_MODE_PREFERRED, TLS_MODE_REQUIRED:
+		cfg.InsecureSkipVerify = true
+	case TLS_MODE_VERIFY_CA:
+		cfg.InsecureSkipVerify = true
+		roots := cfg.RootCAs
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertificateChain(rawCerts, roots)
+		}
+	case TLS_MODE_VERIFY_IDENTITY:
+		cfg.InsecureSkipVerify = false
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+	}
+
+	return cfg
+}
+
+func verifyCertificateChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server sent no certificate")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
@@ -0,0 +1,325 @@
+package myreplication
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type (
+	standInServer struct {
+		listener     net.Listener
+		certificate  tls.Certificate
+		ssl          bool
+		pluginName   string
+		authenticate func(session *standInSession, authData []byte) error
+		command      func(session *standInSession, command []byte) error
+		username     chan string
+	}
+
+	standInSession struct {
+		reader   *packReader
+		writer   *packWriter
+		sequence byte
+		secure   bool
+	}
+)
+
+var (
+	standInScramble = []byte("123456789abcdefghijk")
+)
+
+func newSelfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Generate key fail", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "myreplication stand-in"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("Create certificate fail", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("Parse certificate fail", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func newStandInServer(t *testing.T, certificate tls.Certificate, ssl bool) *standInServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen fail", err)
+	}
+
+	server := &standInServer{
+		listener:    listener,
+		certificate: certificate,
+		ssl:         ssl,
+		pluginName:  _AUTH_NATIVE_PASSWORD,
+		username:    make(chan string, 1),
+	}
+
+	return server
+}
+
+func (s *standInSession) read() ([]byte, error) {
+	pack, err := s.reader.readNextPack()
+	if err != nil {
+		return nil, err
+	}
+	s.sequence = pack.getSequence()
+	return pack.buff, nil
+}
+
+func (s *standInSession) write(data ...byte) error {
+	s.sequence++
+	pack := newPack()
+	pack.Write(data)
+	pack.setSequence(s.sequence)
+	return s.writer.flush(pack)
+}
+
+func (s *standInSession) ok() error {
+	return s.write(_MYSQL_OK, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00)
+}
+
+func (s *standInServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *standInServer) close() {
+	s.listener.Close()
+}
+
+func (s *standInServer) start() *standInServer {
+	go s.serve()
+	return s
+}
+
+func (s *standInServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+func (s *standInServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	capabilities := _CLIENT_ALL_FLAGS | _CLIENT_PLUGIN_AUTH
+	if s.ssl {
+		capabilities |= _CLIENT_SSL
+	}
+
+	handshake := newPack()
+	handshake.WriteByte(_HANDSHAKE_VERSION_10)
+	handshake.writeStringNil("5.7.10-stand-in")
+	handshake.writeUInt32(1)
+	handshake.Write(standInScramble[:8])
+	handshake.WriteByte(0)
+	handshake.writeUInt16(uint16(capabilities))
+	handshake.WriteByte(0x21)
+	handshake.writeUInt16(2)
+	handshake.writeUInt16(uint16(capabilities >> 16))
+	handshake.WriteByte(byte(len(standInScramble) + 1))
+	handshake.Write(make([]byte, 10))
+	handshake.Write(standInScramble[8:])
+	handshake.WriteByte(0)
+	handshake.writeStringNil(s.pluginName)
+
+	session := &standInSession{
+		reader: newPackReader(conn),
+		writer: newPackWriter(conn),
+	}
+
+	if session.writer.flush(handshake) != nil {
+		return
+	}
+
+	buff, err := session.read()
+	if err != nil {
+		return
+	}
+
+	var flags uint32
+	readUint32(buff[0:4], &flags)
+
+	if flags&_CLIENT_SSL == _CLIENT_SSL {
+		tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{s.certificate}})
+		if tlsConn.Handshake() != nil {
+			return
+		}
+		session.reader = newPackReader(tlsConn)
+		session.writer = newPackWriter(tlsConn)
+		session.secure = true
+
+		if buff, err = session.read(); err != nil {
+			return
+		}
+	}
+
+	response := newPackWithBuff(buff[4+4+1+23:])
+	username, _ := response.readNilString()
+	authLength, _ := response.ReadByte()
+	authData := response.Next(int(authLength))
+	select {
+	case s.username <- string(username):
+	default:
+	}
+
+	if s.authenticate != nil {
+		if err = s.authenticate(session, authData); err != nil {
+			errPack := []byte{_MYSQL_ERR, 0x15, 0x04}
+			session.write(append(errPack, []byte("#28000"+err.Error())...)...)
+			return
+		}
+	}
+
+	if session.ok() != nil {
+		return
+	}
+
+	if s.command == nil {
+		//hold connection until client close it
+		conn.Read(make([]byte, 1))
+		return
+	}
+
+	for {
+		command, err := session.read()
+		if err != nil {
+			return
+		}
+
+		if s.command(session, command) != nil {
+			return
+		}
+	}
+}
+
+func TestConnectTLS(t *testing.T) {
+	certificate, roots := newSelfSignedCertificate(t)
+
+	type tlsTestCase struct {
+		mode          TLSMode
+		config        *tls.Config
+		serverSSL     bool
+		expectedError bool
+		expectedTLS   bool
+	}
+
+	testCases := []*tlsTestCase{
+		&tlsTestCase{TLS_MODE_DISABLED, nil, true, false, false},
+		&tlsTestCase{TLS_MODE_PREFERRED, nil, true, false, true},
+		&tlsTestCase{TLS_MODE_PREFERRED, nil, false, false, false},
+		&tlsTestCase{TLS_MODE_REQUIRED, nil, true, false, true},
+		&tlsTestCase{TLS_MODE_REQUIRED, nil, false, true, false},
+		&tlsTestCase{TLS_MODE_VERIFY_CA, &tls.Config{RootCAs: roots}, true, false, true},
+		&tlsTestCase{TLS_MODE_VERIFY_CA, &tls.Config{RootCAs: x509.NewCertPool()}, true, true, false},
+		&tlsTestCase{TLS_MODE_VERIFY_IDENTITY, &tls.Config{RootCAs: roots}, true, false, true},
+		&tlsTestCase{TLS_MODE_VERIFY_IDENTITY, &tls.Config{RootCAs: roots, ServerName: "mysql.example.com"}, true, true, false},
+	}
+
+	for i, testCase := range testCases {
+		server := newStandInServer(t, certificate, testCase.serverSSL).start()
+
+		connection := NewConnection()
+		connection.SetTLS(testCase.mode, testCase.config)
+		err := connection.ConnectAndAuth("127.0.0.1", server.port(), "repl", "secret")
+
+		if testCase.expectedError {
+			if err == nil {
+				t.Fatal("Expected connection error at test", i)
+			}
+			server.close()
+			continue
+		}
+
+		if err != nil {
+			t.Fatal("Connection fail at test", i, err)
+		}
+
+		if username := <-server.username; username != "repl" {
+			t.Fatal(
+				"Incorrect username at test", i,
+				"expected", "repl",
+				"got", username,
+			)
+		}
+
+		_, isTLS := connection.Connection().(*tls.Conn)
+		if isTLS != testCase.expectedTLS {
+			t.Fatal(
+				"Incorrect tls state at test", i,
+				"expected", testCase.expectedTLS,
+				"got", isTLS,
+			)
+		}
+
+		connection.Close()
+		server.close()
+	}
+}
+
+func TestConnectControlDBError(t *testing.T) {
+	certificate, _ := newSelfSignedCertificate(t)
+	server := newStandInServer(t, certificate, true).start()
+	defer server.close()
+
+	cfg := NewConfig()
+	cfg.Addr = server.listener.Addr().String()
+	cfg.User = "repl"
+	cfg.TLSMode = TLS_MODE_REQUIRED
+	//go-sql-driver can not load the time zone of the control DSN
+	cfg.Loc = time.FixedZone("Stand-in/Nowhere", 0)
+
+	connection := NewConnection()
+	tlsConfigName := fmt.Sprintf("myreplication-%p", connection)
+
+	if err := connection.Connect(cfg); err == nil {
+		t.Fatal("Expected control connection error")
+	}
+
+	if connection.Connection() != nil || connection.tlsConfigName != "" {
+		t.Fatal(
+			"Incorrect connection state",
+			"expected", false, "",
+			"got", connection.Connection() != nil, connection.tlsConfigName,
+		)
+	}
+
+	if _, err := mysql.ParseDSN("repl@tcp(127.0.0.1)/?tls=" + tlsConfigName); err == nil {
+		t.Fatal("Expected tls config to be deregistered", tlsConfigName)
+	}
+}
@@ -0,0 +1,183 @@
+package myreplication
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type (
+	TransactionOverflow int
+
+	// Transaction holds the events between BEGIN or GTID and XID or COMMIT,
+	// a statement outside of BEGIN is a transaction of its own
+	Transaction struct {
+		gtidEvent       *GtidEvent
+		xid             uint64
+		commitTimestamp uint32
+		events          []Event
+		size            int
+		chunk           int
+		partial         bool
+		explicit        bool
+	}
+)
+
+const (
+	// TRANSACTION_OVERFLOW_CHUNK delivers a transaction over the limit in
+	// several partial parts, only the last one has the commit data
+	TRANSACTION_OVERFLOW_CHUNK TransactionOverflow = iota
+	// TRANSACTION_OVERFLOW_ERROR makes NextTransaction fail with TRANSACTION_TOO_LARGE_ERR,
+	// the stream stops in the middle of the transaction and must be closed
+	TRANSACTION_OVERFLOW_ERROR
+)
+
+var (
+	TRANSACTION_TOO_LARGE_ERR = errors.New("transaction exceeds the memory limit")
+)
+
+// GetGTID returns an empty string when gtid_mode is off
+func (t *Transaction) GetGTID() string {
+	if t.gtidEvent == nil {
+		return ""
+	}
+	return t.gtidEvent.GetGTID()
+}
+
+func (t *Transaction) GetGtidEvent() *GtidEvent {
+	return t.gtidEvent
+}
+
+// GetXid returns 0 for a transaction committed by a COMMIT query or a
+// statement outside of BEGIN
+func (t *Transaction) GetXid() uint64 {
+	return t.xid
+}
+
+// GetCommitTimestamp returns the timestamp of the commit event, 0 for a partial transaction
+func (t *Transaction) GetCommitTimestamp() uint32 {
+	return t.commitTimestamp
+}
+
+// GetEvents returns the query and row events in binlog order,
+// BEGIN, COMMIT and XID are not included
+func (t *Transaction) GetEvents() []Event {
+	return t.events
+}
+
+// GetSize returns the binlog size of the buffered events
+func (t *Transaction) GetSize() int {
+	return t.size
+}
+
+// IsPartial is true when the rest of the transaction comes with the next
+// NextTransaction calls
+func (t *Transaction) IsPartial() bool {
+	return t.partial
+}
+
+// GetChunk returns the number of the part of a transaction over the limit, starting from 0
+func (t *Transaction) GetChunk() int {
+	return t.chunk
+}
+
+func (t *Transaction) add(event Event) {
+	t.events = append(t.events, event)
+	t.size += int(event.Header().EventSize)
+}
+
+func (t *Transaction) commit(header *eventLogHeader) *Transaction {
+	t.commitTimestamp = header.Timestamp
+	return t
+}
+
+// SetTransactionLimit caps the binlog size of the events NextTransaction
+// buffers, 0 is no limit
+func (ev *EventLog) SetTransactionLimit(maxBytes int, overflow TransactionOverflow) {
+	ev.transactionLimit = maxBytes
+	ev.transactionOverflow = overflow
+}
+
+func (ev *EventLog) NextTransaction() (*Transaction, error) {
+	return ev.NextTransactionContext(context.Background())
+}
+
+// NextTransactionContext reads events up to the end of the next transaction,
+// it must not be mixed with GetEvent on the same EventLog
+func (ev *EventLog) NextTransactionContext(ctx context.Context) (transaction *Transaction, err error) {
+	stop := ev.mysqlConnection.watchContext(ctx)
+	defer func() {
+		stop()
+		err = contextError(ctx, err)
+	}()
+
+	for {
+		event, err := ev.nextEvent(true, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := event.(*HeartBeatEvent); ok {
+			continue
+		}
+
+		if ev.transaction == nil {
+			if _, ok := event.(*PreviousGtidsEvent); ok {
+				continue
+			}
+			ev.transaction = &Transaction{}
+		}
+		current := ev.transaction
+
+		switch e := event.(type) {
+		case *GtidEvent:
+			current.gtidEvent = e
+			continue
+		case *XidEvent:
+			current.xid = e.TransactionId
+			ev.transaction = nil
+			return current.commit(e.eventLogHeader), nil
+		case *QueryEvent:
+			switch strings.ToUpper(e.query) {
+			case "BEGIN":
+				current.explicit = true
+				continue
+			case "COMMIT", "ROLLBACK":
+				ev.transaction = nil
+				return current.commit(e.eventLogHeader), nil
+			}
+
+			current.add(e)
+			if !current.explicit {
+				ev.transaction = nil
+				return current.commit(e.eventLogHeader), nil
+			}
+		default:
+			current.add(e)
+		}
+
+		if ev.transactionLimit > 0 && current.size > ev.transactionLimit {
+			if ev.transactionOverflow == TRANSACTION_OVERFLOW_ERROR {
+				ev.transaction = nil
+				return nil, TRANSACTION_TOO_LARGE_ERR
+			}
+
+			current.partial = true
+			ev.transaction = &Transaction{
+				gtidEvent: current.gtidEvent,
+				chunk:     current.chunk + 1,
+				explicit:  current.explicit,
+			}
+			return current, nil
+		}
+	}
+}
+
+// isTransactionControl is true for the queries starting and ending a transaction
+func isTransactionControl(query string) bool {
+	switch strings.ToUpper(query) {
+	case "BEGIN", "COMMIT", "ROLLBACK":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package myreplication
+
+import (
+	"net"
+	"testing"
+)
+
+// writeStandInEvents sends the events like the master does after COM_BINLOG_DUMP
+func writeStandInEvents(server net.Conn, events ...[]byte) {
+	events = append([][]byte{standInEvent(_FORMAT_DESCRIPTION_EVENT, 0, standInFormatDescriptionBody())}, events...)
+	writeStandInPackets(server, events...)
+}
+
+func writeStandInPackets(server net.Conn, events ...[]byte) {
+	for i, event := range events {
+		packet := make([]byte, 5, 5+len(event))
+		writeThreeByteUInt32(packet, uint32(len(event)+1))
+		packet[3] = byte(i + 1)
+		packet = append(packet, event...)
+		if _, err := server.Write(packet); err != nil {
+			return
+		}
+	}
+}
+
+func standInIntVarEvent(value uint64) []byte {
+	return standInEvent(_INTVAR_EVENT, 100, standInIntVarBody(value))
+}
+
+func standInXidEvent(xid uint64) []byte {
+	return standInEvent(_XID_EVENT, 100, standInXidBody(xid))
+}
+
+func standInGtidEvent(sid string, gno int64) []byte {
+	return standInEvent(_GTID_EVENT, 100, standInGtidBody(sid, gno))
+}
+
+func standInQueryEvent(query string) []byte {
+	return standInEvent(_QUERY_EVENT, 100, standInQueryBody("test", query))
+}
+
+func TestNextTransaction(t *testing.T) {
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 0)
+
+	sid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+
+	go writeStandInEvents(server,
+		standInGtidEvent(sid, 1),
+		standInQueryEvent("CREATE TABLE t (id int)"),
+		standInGtidEvent(sid, 2),
+		standInQueryEvent("BEGIN"),
+		standInIntVarEvent(1),
+		standInQueryEvent("INSERT INTO t VALUES (NULL)"),
+		standInXidEvent(10),
+		standInQueryEvent("BEGIN"),
+		standInQueryEvent("INSERT INTO m VALUES (1)"),
+		standInQueryEvent("COMMIT"),
+	)
+
+	type transactionTestCase struct {
+		gtid    string
+		xid     uint64
+		queries []string
+	}
+
+	testCases := []*transactionTestCase{
+		&transactionTestCase{sid + ":1", 0, []string{"CREATE TABLE t (id int)"}},
+		&transactionTestCase{sid + ":2", 10, []string{"", "INSERT INTO t VALUES (NULL)"}},
+		&transactionTestCase{"", 0, []string{"INSERT INTO m VALUES (1)"}},
+	}
+
+	for i, testCase := range testCases {
+		transaction, err := el.NextTransaction()
+		if err != nil {
+			t.Fatal("Next transaction fail at test", i, err)
+		}
+
+		if transaction.GetGTID() != testCase.gtid || transaction.GetXid() != testCase.xid || transaction.IsPartial() {
+			t.Fatal(
+				"Incorrect transaction at test", i,
+				"expected", testCase.gtid, testCase.xid,
+				"got", transaction.GetGTID(), transaction.GetXid(), transaction.IsPartial(),
+			)
+		}
+
+		if transaction.GetCommitTimestamp() == 0 {
+			t.Fatal("Incorrect commit timestamp at test", i)
+		}
+
+		if len(transaction.GetEvents()) != len(testCase.queries) {
+			t.Fatal(
+				"Incorrect events count at test", i,
+				"expected", len(testCase.queries),
+				"got", len(transaction.GetEvents()),
+			)
+		}
+
+		for j, event := range transaction.GetEvents() {
+			var query string
+			if queryEvent, ok := event.(*QueryEvent); ok {
+				query = queryEvent.GetQuery()
+			}
+
+			if query != testCase.queries[j] {
+				t.Fatal(
+					"Incorrect event at test", i, j,
+					"expected", testCase.queries[j],
+					"got", event,
+				)
+			}
+		}
+	}
+}
+
+func TestNextTransactionLimit(t *testing.T) {
+	events := [][]byte{
+		standInQueryEvent("BEGIN"),
+		standInIntVarEvent(1),
+		standInIntVarEvent(2),
+		standInIntVarEvent(3),
+		standInXidEvent(10),
+	}
+	intVarSize := len(events[1])
+
+	connection, server := newPipeConnection()
+	el := newEventLog(connection, 0)
+	el.SetTransactionLimit(2*intVarSize-1, TRANSACTION_OVERFLOW_CHUNK)
+
+	go writeStandInEvents(server, events...)
+
+	expectedChunks := []int{2, 1}
+	for i, expectedEvents := range expectedChunks {
+		transaction, err := el.NextTransaction()
+		if err != nil {
+			t.Fatal("Next transaction fail at chunk", i, err)
+		}
+
+		partial := i < len(expectedChunks)-1
+		if transaction.GetChunk() != i || transaction.IsPartial() != partial || len(transaction.GetEvents()) != expectedEvents {
+			t.Fatal(
+				"Incorrect chunk", i,
+				"expected", expectedEvents, partial,
+				"got", len(transaction.GetEvents()), transaction.IsPartial(), transaction.GetChunk(),
+			)
+		}
+	}
+
+	server.Close()
+
+	connection, server = newPipeConnection()
+	defer server.Close()
+	el = newEventLog(connection, 0)
+	el.SetTransactionLimit(2*intVarSize-1, TRANSACTION_OVERFLOW_ERROR)
+
+	go writeStandInEvents(server, events...)
+
+	if _, err := el.NextTransaction(); err != TRANSACTION_TOO_LARGE_ERR {
+		t.Fatal(
+			"Incorrect transaction error",
+			"expected", TRANSACTION_TOO_LARGE_ERR,
+			"got", err,
+		)
+	}
+}
+
+func TestFilePositionGTIDSet(t *testing.T) {
+	connection, server := newPipeConnection()
+	defer server.Close()
+	el := newEventLog(connection, 4)
+
+	sid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	previous, _ := ParseGTIDSet(sid + ":1-5")
+
+	go writeStandInEvents(server,
+		standInEvent(_PREVIOUS_GTIDS_EVENT, 100, previous.encode()),
+		standInGtidEvent(sid, 6),
+		standInQueryEvent("BEGIN"),
+		standInIntVarEvent(1),
+		standInXidEvent(10),
+		standInGtidEvent(sid, 7),
+		standInQueryEvent("BEGIN"),
+	)
+
+	type gtidSetTestCase struct {
+		expected string
+	}
+
+	//nil before the PREVIOUS_GTIDS_EVENT, the XidEvent is skipped and the open
+	//transaction is not added
+	testCases := []*gtidSetTestCase{
+		&gtidSetTestCase{sid + ":1-5"},
+		&gtidSetTestCase{sid + ":1-5"},
+		&gtidSetTestCase{sid + ":1-5"},
+		&gtidSetTestCase{sid + ":1-5"},
+		&gtidSetTestCase{sid + ":1-6"},
+		&gtidSetTestCase{sid + ":1-6"},
+	}
+
+	if el.GetGTIDSet() != nil {
+		t.Fatal("Incorrect gtid set", "expected", nil, "got", el.GetGTIDSet())
+	}
+
+	for i, testCase := range testCases {
+		if _, err := el.GetEvent(); err != nil {
+			t.Fatal("Incorrect event at test", i, "got", err)
+		}
+
+		if el.GetGTIDSet() == nil || el.GetGTIDSet().String() != testCase.expected {
+			t.Fatal(
+				"Incorrect gtid set at test", i,
+				"expected", testCase.expected,
+				"got", el.GetGTIDSet(),
+			)
+		}
+	}
+}